@@ -0,0 +1,244 @@
+package comfyconf
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//bindValue sets decoded configuration value to field. Objects are bound to structs by `comfyname` tag or by
+//case insensitive field name, arrays are bound to typed slices and numbers and strings are converted to field type
+func bindValue(field reflect.Value, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(field.Type().Elem())
+
+		if err := bindValue(elem.Elem(), value); err != nil {
+			return err
+		}
+
+		field.Set(elem)
+
+		return nil
+	case reflect.Interface:
+		if reflect.TypeOf(value).AssignableTo(field.Type()) {
+			field.Set(reflect.ValueOf(value))
+			return nil
+		}
+	case reflect.Struct:
+		object, isOk := value.(map[string]interface{})
+
+		if !isOk {
+			break
+		}
+
+		return bindStruct(field, object)
+	case reflect.Slice:
+		items, isOk := value.([]interface{})
+
+		if !isOk {
+			break
+		}
+
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+
+		for i, item := range items {
+			if err := bindValue(slice.Index(i), item); err != nil {
+				return fmt.Errorf("%d: %v", i, err)
+			}
+		}
+
+		field.Set(slice)
+
+		return nil
+	case reflect.Map:
+		object, isOk := value.(map[string]interface{})
+
+		if !isOk || field.Type().Key().Kind() != reflect.String {
+			break
+		}
+
+		m := reflect.MakeMapWithSize(field.Type(), len(object))
+
+		for k, v := range object {
+			elem := reflect.New(field.Type().Elem()).Elem()
+
+			if err := bindValue(elem, v); err != nil {
+				return fmt.Errorf("%s: %v", k, err)
+			}
+
+			m.SetMapIndex(reflect.ValueOf(k).Convert(field.Type().Key()), elem)
+		}
+
+		field.Set(m)
+
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, isOk := bindInt(value); isOk {
+			if field.OverflowInt(n) {
+				return fmt.Errorf("%v overflows %s", value, field.Type())
+			}
+
+			field.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, isOk := bindUint(value); isOk {
+			if field.OverflowUint(n) {
+				return fmt.Errorf("%v overflows %s", value, field.Type())
+			}
+
+			field.SetUint(n)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, isOk := bindFloat(value); isOk {
+			if field.OverflowFloat(n) {
+				return fmt.Errorf("%v overflows %s", value, field.Type())
+			}
+
+			field.SetFloat(n)
+			return nil
+		}
+	case reflect.Bool:
+		if b, isOk := value.(bool); isOk {
+			field.SetBool(b)
+			return nil
+		}
+
+		if b, isOk := convertString(boolType, fmt.Sprint(value)); isOk {
+			field.SetBool(b.(bool))
+			return nil
+		}
+	case reflect.String:
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			field.SetString(fmt.Sprint(value))
+			return nil
+		}
+	}
+
+	return fmt.Errorf("can not bind %s to %s", jsonTypeName(value), field.Type())
+}
+
+//bindStruct sets values of object to exported fields of struct
+func bindStruct(structure reflect.Value, object map[string]interface{}) error {
+	t := structure.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if len(f.PkgPath) != 0 {
+			continue
+		}
+
+		name, hasTag := f.Tag.Lookup(tagName)
+		value, isExist := object[name]
+
+		if !hasTag {
+			var err error
+
+			if value, isExist, err = lookupField(object, f.Name); err != nil {
+				return fmt.Errorf("%s: %v", f.Name, err)
+			}
+		}
+
+		if !isExist {
+			continue
+		}
+
+		if err := bindValue(structure.Field(i), value); err != nil {
+			return fmt.Errorf("%s: %v", f.Name, err)
+		}
+	}
+
+	return nil
+}
+
+//lookupField returns value of object by field name. Key, that is equal to field name, is preferred,
+//otherwise single key, that matches field name case insensitively, is used
+func lookupField(object map[string]interface{}, name string) (interface{}, bool, error) {
+	if v, isOk := object[name]; isOk {
+		return v, true, nil
+	}
+
+	matched := make([]string, 0, 1)
+
+	for k := range object {
+		if strings.EqualFold(k, name) {
+			matched = append(matched, k)
+		}
+	}
+
+	if len(matched) == 0 {
+		return nil, false, nil
+	}
+
+	if len(matched) > 1 {
+		sort.Strings(matched)
+		return nil, false, fmt.Errorf("ambiguous keys %q", matched)
+	}
+
+	return object[matched[0]], true, nil
+}
+
+//bindInt converts whole number or string with whole number to int64
+func bindInt(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+
+		return int64(v), true
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	}
+
+	return 0, false
+}
+
+//bindUint converts non negative whole number or string with such number to uint64
+func bindUint(value interface{}) (uint64, bool) {
+	switch v := value.(type) {
+	case int:
+		return uint64(v), v >= 0
+	case float64:
+		if v != math.Trunc(v) || v < 0 || v >= math.MaxUint64 {
+			return 0, false
+		}
+
+		return uint64(v), true
+	case string:
+		n, err := strconv.ParseUint(v, 10, 64)
+		return n, err == nil
+	}
+
+	return 0, false
+}
+
+//bindFloat converts number or string with number to float64
+func bindFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	}
+
+	return 0, false
+}
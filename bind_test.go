@@ -0,0 +1,56 @@
+package comfyconf
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindValue_Numbers(t *testing.T) {
+	var n int
+	var u uint
+	var f float64
+
+	assert.NoError(t, bindValue(reflect.ValueOf(&n).Elem(), "12"))
+	assert.Equal(t, 12, n)
+
+	assert.EqualError(t, bindValue(reflect.ValueOf(&n).Elem(), "12abc"), "can not bind string to int")
+	assert.EqualError(t, bindValue(reflect.ValueOf(&n).Elem(), 1.5), "can not bind number to int")
+	assert.EqualError(t, bindValue(reflect.ValueOf(&u).Elem(), -1), "can not bind int to uint")
+
+	assert.NoError(t, bindValue(reflect.ValueOf(&f).Elem(), "1.5"))
+	assert.Equal(t, 1.5, f)
+
+	assert.EqualError(t, bindValue(reflect.ValueOf(&f).Elem(), "1.5s"), "can not bind string to float64")
+}
+
+func TestBindValue_Overflow(t *testing.T) {
+	var i8 int8
+	var u8 uint8
+	var f32 float32
+
+	assert.NoError(t, bindValue(reflect.ValueOf(&i8).Elem(), 127))
+	assert.Equal(t, int8(127), i8)
+
+	assert.EqualError(t, bindValue(reflect.ValueOf(&i8).Elem(), 300), "300 overflows int8")
+	assert.EqualError(t, bindValue(reflect.ValueOf(&u8).Elem(), "256"), "256 overflows uint8")
+	assert.EqualError(t, bindValue(reflect.ValueOf(&f32).Elem(), 1e300), "1e+300 overflows float32")
+	assert.Equal(t, int8(127), i8)
+}
+
+func TestBindValue_CaseInsensitiveKeys(t *testing.T) {
+	var server struct {
+		Host string
+		Port int
+	}
+
+	value := reflect.ValueOf(&server).Elem()
+
+	assert.NoError(t, bindValue(value, map[string]interface{}{"host": "a", "Host": "b", "PORT": 80}))
+	assert.Equal(t, "b", server.Host)
+	assert.Equal(t, 80, server.Port)
+
+	assert.EqualError(t, bindValue(value, map[string]interface{}{"host": "a", "HOST": "b"}),
+		`Host: ambiguous keys ["HOST" "host"]`)
+}
@@ -0,0 +1,214 @@
+package comfyconf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+//ErrorHandling defines how Conf.Parse behaves, when parsing fails or help is requested
+type ErrorHandling int
+
+const (
+	//ContinueOnError returns error from Parse
+	ContinueOnError ErrorHandling = iota
+	//ExitOnError exits program with status 0 after help or version, and with status 2 after error
+	ExitOnError
+	//PanicOnError panics with error
+	PanicOnError
+)
+
+var (
+	//ErrHelp is returned by Parse, when help was requested by `-h` or `--help` flag and printed
+	ErrHelp = errors.New("comfyconf: help requested")
+	//ErrVersion is returned by Parse, when version was requested by `--version` flag and printed
+	ErrVersion = errors.New("comfyconf: version requested")
+	//ErrComplete is returned by Parse, when completion candidates were requested by hidden `__complete`
+	//command and printed
+	ErrComplete = errors.New("comfyconf: completion requested")
+)
+
+//osExit used for exiting program with ExitOnError mode
+var osExit = os.Exit
+
+//SetErrorHandling sets how Parse behaves on errors and on help and version requests
+func (c *Conf) SetErrorHandling(errorHandling ErrorHandling) {
+	c.errorHandling = errorHandling
+}
+
+//SetOutput sets writer for help and version output. Standard output is used by default
+func (c *Conf) SetOutput(output io.Writer) {
+	c.output = output
+}
+
+//SetVersion sets program version, that is printed by built-in `--version` flag
+func (c *Conf) SetVersion(version string) {
+	c.version = version
+}
+
+//DisableBuiltins disables built-in `-h`, `--help` and `--version` flags and hidden `__complete` command
+func (c *Conf) DisableBuiltins() {
+	c.builtinsDisabled = true
+}
+
+func (c *Conf) out() io.Writer {
+	if c.output == nil {
+		return os.Stdout
+	}
+
+	return c.output
+}
+
+//registerBuiltin registers built-in existence option, if its names are not used by other options
+func (c *Conf) registerBuiltin(shortName string, fullName string, description string) *Option {
+	opt, isRegistered := c.builtinOption(shortName, fullName, description)
+
+	if opt == nil || isRegistered {
+		return opt
+	}
+
+	c.declared++
+	opt.index = c.declared
+	c.options[opt.key] = opt
+
+	return opt
+}
+
+//builtinOption returns registered built-in option or creates new one without registering it.
+//Returns nil, if full name is used by other option, short name is dropped, if it is used
+func (c *Conf) builtinOption(shortName string, fullName string, description string) (*Option, bool) {
+	for optKey, opt := range c.options {
+		if opt.builtin && optKey.fullName == fullName {
+			return opt, true
+		}
+	}
+
+	if c.Lookup(fullName) != nil {
+		return nil, false
+	}
+
+	if len(shortName) != 0 && c.Lookup(shortName) != nil {
+		shortName = ""
+	}
+
+	key := OptionKey{shortName, fullName}
+	variable := false
+
+	return &Option{
+		key:          key,
+		index:        c.declared + 1,
+		defaultValue: false,
+		variable:     &variable,
+		optionType:   existenceType,
+		description:  description,
+		builtin:      true,
+	}, false
+}
+
+//pendingBuiltins returns built-in options, that are not registered yet, without registering them
+func (c *Conf) pendingBuiltins() []*Option {
+	options := make([]*Option, 0, 2)
+
+	if c.builtinsDisabled {
+		return options
+	}
+
+	if help, isRegistered := c.builtinOption("h", "help", "Show help and exit"); help != nil && !isRegistered {
+		options = append(options, help)
+	}
+
+	if len(c.version) == 0 {
+		return options
+	}
+
+	if version, isRegistered := c.builtinOption("", "version", "Show version and exit"); version != nil && !isRegistered {
+		version.index += len(options)
+		options = append(options, version)
+	}
+
+	return options
+}
+
+//registerBuiltins registers built-in help and version options, if they are enabled
+func (c *Conf) registerBuiltins() (help *Option, version *Option) {
+	if c.builtinsDisabled {
+		return nil, nil
+	}
+
+	help = c.registerBuiltin("h", "help", "Show help and exit")
+
+	if len(c.version) != 0 {
+		version = c.registerBuiltin("", "version", "Show version and exit")
+	}
+
+	return help, version
+}
+
+//applyBuiltins registers built-in help and version options and checks them in command line flags.
+//Other middlewares are ignored, so `version` key of configuration file is not treated as request
+func (c *Conf) applyBuiltins() error {
+	help, version := c.registerBuiltins()
+
+	if !c.builtinsDisabled {
+		if err := c.applyComplete(); err != nil {
+			return err
+		}
+	}
+
+	if c.isBuiltinRequested(help) {
+		if err := c.WriteUsage(c.out()); err != nil {
+			return err
+		}
+
+		return ErrHelp
+	}
+
+	if c.isBuiltinRequested(version) {
+		if _, err := fmt.Fprintf(c.out(), "%s %s\n", c.programName(), c.version); err != nil {
+			return err
+		}
+
+		return ErrVersion
+	}
+
+	return nil
+}
+
+func (c *Conf) isBuiltinRequested(opt *Option) bool {
+	if opt == nil {
+		return false
+	}
+
+	for _, m := range c.middleware {
+		if flags, isOk := m.(*Flags); isOk {
+			if v, _ := flags.ParseExistence(opt.key.shortName, opt.key.fullName); v {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+//handleError handles parsing error according to error handling mode
+func (c *Conf) handleError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch c.errorHandling {
+	case ExitOnError:
+		if err == ErrHelp || err == ErrVersion || err == ErrComplete {
+			osExit(0)
+			return err
+		}
+
+		fmt.Fprintln(os.Stderr, err)
+		osExit(2)
+	case PanicOnError:
+		panic(err)
+	}
+
+	return err
+}
@@ -0,0 +1,121 @@
+package comfyconf
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConf_Parse_Help(t *testing.T) {
+	conf := prepareConf([]string{"-h"}, "=")
+	conf.SetProgram("app", "")
+
+	var buffer bytes.Buffer
+	conf.SetOutput(&buffer)
+	conf.String("p", "port", "80", "Port")
+
+	assert.Equal(t, ErrHelp, conf.Parse())
+	assert.Contains(t, buffer.String(), "Usage: app [options]")
+	assert.Contains(t, buffer.String(), "-h, --help")
+
+	buffer.Reset()
+	conf = prepareConf([]string{"--help"}, "=")
+	conf.SetOutput(&buffer)
+	conf.DisableBuiltins()
+
+	assert.NoError(t, conf.Parse())
+	assert.Empty(t, buffer.String())
+}
+
+func TestConf_Parse_Version(t *testing.T) {
+	conf := New(NewJSONWithCustomReader(func(j *JSON) ([]byte, error) {
+		return []byte(`{"version": "2"}`), nil
+	}), prepareFlags([]string{}, "="))
+	conf.SetProgram("app", "")
+	conf.SetVersion("1.2.3")
+
+	var buffer bytes.Buffer
+	conf.SetOutput(&buffer)
+
+	assert.NoError(t, conf.Parse())
+
+	conf.AddMiddleware(prepareFlags([]string{"--version"}, "="))
+
+	assert.Equal(t, ErrVersion, conf.Parse())
+	assert.Equal(t, "app 1.2.3\n", buffer.String())
+}
+
+func TestConf_Parse_Builtins_NameConflict(t *testing.T) {
+	conf := prepareConf([]string{"-h=db.local"}, "=")
+
+	host := conf.String("h", "host", "", "Host")
+
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, "db.local", *host)
+
+	help := conf.Lookup("help")
+	assert.NotNil(t, help)
+	assert.Equal(t, "", help.key.shortName)
+}
+
+func TestConf_Parse_ErrorHandling(t *testing.T) {
+	defer func() {
+		osExit = os.Exit
+	}()
+
+	var exitCode int
+	osExit = func(code int) {
+		exitCode = code
+	}
+
+	conf := prepareConf([]string{"-h"}, "=")
+	conf.SetOutput(&bytes.Buffer{})
+	conf.SetErrorHandling(ExitOnError)
+
+	conf.Parse()
+	assert.Equal(t, 0, exitCode)
+
+	conf = prepareConf([]string{"--a=${b}"}, "=")
+	conf.SetErrorHandling(ExitOnError)
+	conf.SetInterpolation(true)
+	conf.String("", "a", "", "A")
+
+	conf.Parse()
+	assert.Equal(t, 2, exitCode)
+
+	conf.SetErrorHandling(PanicOnError)
+	assert.Panics(t, func() {
+		conf.Parse()
+	})
+}
+
+func TestConf_Reload_ErrorHandling(t *testing.T) {
+	defer func() {
+		osExit = os.Exit
+	}()
+
+	exited := false
+	osExit = func(code int) {
+		exited = true
+	}
+
+	content := `{"port": 80}`
+	conf := New(NewJSONWithCustomReader(func(j *JSON) ([]byte, error) {
+		return []byte(content), nil
+	}))
+	conf.SetErrorHandling(ExitOnError)
+	conf.Int("p", "port", 0, "Port")
+
+	assert.NoError(t, conf.Parse())
+
+	content = `{"port": 80`
+	assert.Error(t, conf.Reload())
+	assert.False(t, exited)
+
+	conf.SetErrorHandling(PanicOnError)
+	assert.NotPanics(t, func() {
+		assert.Error(t, conf.Reload())
+	})
+}
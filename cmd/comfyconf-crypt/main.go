@@ -0,0 +1,222 @@
+//Command comfyconf-crypt generates keys and encrypts and decrypts configuration values and files,
+//that are decrypted by comfyconf during Parse.
+//
+//	comfyconf-crypt keygen [aes-gcm|age]
+//	comfyconf-crypt encrypt --recipient=age1... [value]
+//	comfyconf-crypt decrypt --identity=@key.txt [value]
+//
+//Value is read from standard input, when it is not provided. Whole JSON file is encrypted, when it is passed
+//to standard input of encrypt. Decrypt replaces every `ENC[...]` value of input, so file with encrypted values
+//can be read before editing
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/drewoko/comfyconf"
+)
+
+var encryptedExpr = regexp.MustCompile(`ENC\[[a-z0-9-]+:[A-Za-z0-9+/=]*]`)
+
+func main() {
+	conf := comfyconf.New(comfyconf.NewEnvWithPrefix("COMFYCONF_"), comfyconf.NewFlags())
+	conf.SetProgram("comfyconf-crypt", "[keygen|encrypt|decrypt] [options] [value]")
+	conf.SetDescription("Generates keys and encrypts and decrypts configuration values in ENC[...] form.")
+	conf.AddCommand("keygen", "Generate aes-gcm key or age identity, age is default")
+	conf.AddCommand("encrypt", "Encrypt value or standard input with key or recipient")
+	conf.AddCommand("decrypt", "Decrypt value or all ENC[...] values of standard input with keys or identities")
+	conf.SetErrorHandling(comfyconf.ExitOnError)
+	conf.SetFileRefs(true)
+
+	key := conf.Secret("k", "key", "", "Base64 encoded AES key of aes-gcm scheme")
+	identity := conf.Secret("i", "identity", "", "Age identity, that decrypts values of age scheme")
+	recipient := conf.String("r", "recipient", "", "Age recipient, that encrypts values of age scheme")
+
+	conf.Parse()
+
+	command, value := arguments()
+
+	var err error
+
+	switch command {
+	case "keygen":
+		err = keygen(value)
+	case "encrypt":
+		err = encrypt(key.Reveal(), *recipient, value)
+	case "decrypt":
+		err = decrypt(key.Reveal(), identity.Reveal(), value)
+	default:
+		conf.WriteUsage(os.Stderr)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "comfyconf-crypt:", err)
+		os.Exit(1)
+	}
+}
+
+//arguments returns command and optional value from arguments, that are not flags
+func arguments() (string, *string) {
+	positional := make([]string, 0)
+
+	for _, arg := range os.Args[1:] {
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+		}
+	}
+
+	switch len(positional) {
+	case 0:
+		return "", nil
+	case 1:
+		return positional[0], nil
+	}
+
+	return positional[0], &positional[1]
+}
+
+//input returns value or content of standard input
+func input(value *string) ([]byte, error) {
+	if value != nil {
+		return []byte(*value), nil
+	}
+
+	return ioutil.ReadAll(os.Stdin)
+}
+
+func keygen(scheme *string) error {
+	if scheme != nil && *scheme == comfyconf.SchemeAESGCM {
+		key, err := comfyconf.GenerateAESGCMKey()
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(key)
+
+		return nil
+	}
+
+	if scheme != nil && *scheme != comfyconf.SchemeAge {
+		return fmt.Errorf("unsupported scheme %q", *scheme)
+	}
+
+	identity, err := comfyconf.GenerateX25519Identity()
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("# public key:", identity.Recipient())
+	fmt.Println(identity)
+
+	return nil
+}
+
+func encrypt(key string, recipient string, value *string) error {
+	var encrypter comfyconf.Encrypter
+	var err error
+
+	switch {
+	case len(recipient) != 0:
+		encrypter, err = comfyconf.ParseX25519Recipient(recipient)
+	case len(key) != 0:
+		encrypter, err = comfyconf.ParseAESGCMKey(key)
+	default:
+		err = errors.New("--recipient or --key is required")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := input(value)
+
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := comfyconf.EncryptValue(encrypter, plaintext)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(encrypted)
+
+	return nil
+}
+
+func decrypt(key string, identity string, value *string) error {
+	decrypters := make([]comfyconf.Decrypter, 0)
+
+	if len(key) != 0 {
+		aesGCM, err := comfyconf.ParseAESGCMKey(key)
+
+		if err != nil {
+			return err
+		}
+
+		decrypters = append(decrypters, aesGCM)
+	}
+
+	if len(identity) != 0 {
+		ageIdentity, err := comfyconf.ParseX25519Identity(identity)
+
+		if err != nil {
+			return err
+		}
+
+		decrypters = append(decrypters, ageIdentity)
+	}
+
+	if len(decrypters) == 0 {
+		return errors.New("--identity or --key is required")
+	}
+
+	content, err := input(value)
+
+	if err != nil {
+		return err
+	}
+
+	decrypter := comfyconf.Decrypters(decrypters...)
+
+	//whole encrypted file
+	if trimmed := strings.TrimSpace(string(content)); comfyconf.IsEncrypted(trimmed) {
+		plaintext, err := comfyconf.DecryptValue(decrypter, trimmed)
+
+		if err != nil {
+			return err
+		}
+
+		os.Stdout.Write(plaintext)
+
+		return nil
+	}
+
+	decrypted := encryptedExpr.ReplaceAllStringFunc(string(content), func(encrypted string) string {
+		plaintext, decryptErr := comfyconf.DecryptValue(decrypter, encrypted)
+
+		if decryptErr != nil {
+			err = decryptErr
+			return encrypted
+		}
+
+		return string(plaintext)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(decrypted)
+
+	return nil
+}
@@ -1,18 +1,25 @@
 package comfyconf
 
 import (
-	"bytes"
 	"fmt"
+	"io"
+	"os"
 	"reflect"
+	"sort"
+	"sync"
 )
 
-const tagName string = "comfyname"
+const (
+	tagName        string = "comfyname"
+	defaultTagName string = "default"
+)
 
 //New returns pointer to new Conf instance with provided middleware
 func New(middleware ...Middleware) *Conf {
 	return &Conf{
-		options:    make(map[OptionKey]*Option),
-		middleware: middleware,
+		options:      make(map[OptionKey]*Option),
+		middleware:   middleware,
+		fileRefLimit: DefaultFileRefLimit,
 	}
 }
 
@@ -21,55 +28,207 @@ func New(middleware ...Middleware) *Conf {
 type Conf struct {
 	options    map[OptionKey]*Option
 	middleware []Middleware
+
+	fileRefs      bool
+	fileRefLimit  int64
+	interpolation bool
+	decrypter     Decrypter
+	encrypter     Encrypter
+
+	profileKey *OptionKey
+	profile    string
+
+	declared    int
+	program     string
+	synopsis    string
+	description string
+	commands    []docEntry
+	examples    []docEntry
+
+	builtinsDisabled bool
+	version          string
+	errorHandling    ErrorHandling
+	output           io.Writer
+
+	mu sync.Mutex
 }
 
-//Parse initializes middlewares and populates all arguments with parsed data
-func (c *Conf) Parse() (err error) {
+//Parse initializes middlewares and populates all arguments with parsed data.
+//Returns ErrHelp or ErrVersion, when help or version was requested and printed,
+//errors are handled according to error handling mode of Conf
+func (c *Conf) Parse() error {
+	return c.handleError(c.parse())
+}
+
+func (c *Conf) parse() (err error) {
 	err = c.prepare()
 
 	if err != nil {
 		return
 	}
 
+	err = c.applyBuiltins()
+
+	if err != nil {
+		return
+	}
+
+	err = c.applyProfile()
+
+	if err != nil {
+		return
+	}
+
 	for optKey, opt := range c.options {
-		for _, m := range c.middleware {
-			var isOk bool
-			var r interface{}
-
-			switch opt.GetOptionType() {
-			case stringType:
-				r, isOk = m.ParseString(optKey.shortName, optKey.fullName)
-			case boolType:
-				r, isOk = m.ParseBool(optKey.shortName, optKey.fullName)
-			case intType:
-				r, isOk = m.ParseInt(optKey.shortName, optKey.fullName)
-			case existenceType:
-				r, isOk = m.ParseExistence(optKey.shortName, optKey.fullName)
-			case sliceType:
-				r, isOk = m.ParseSlice(optKey.shortName, optKey.fullName)
-			}
+		err = c.parseOption(optKey, opt)
 
-			if !isOk {
-				continue
-			}
+		if err != nil {
+			return
+		}
+	}
+
+	err = c.strictErrors()
+
+	if err != nil {
+		return
+	}
+
+	if c.interpolation {
+		err = c.interpolate()
+
+		if err != nil {
+			return
+		}
+	}
+
+	err = c.validate()
+
+	if err != nil {
+		return
+	}
+
+	c.snapshot()
+
+	return nil
+}
 
-			opt.Put(r)
+//strictErrors returns errors of all middlewares, that implement StrictMiddleware interface
+func (c *Conf) strictErrors() error {
+	errs := make(StrictErrors, 0)
+
+	for _, m := range c.middleware {
+		if strict, isOk := m.(StrictMiddleware); isOk {
+			errs = append(errs, strict.StrictErrors()...)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(errs, func(i, k int) bool {
+		return errs[i].Error() < errs[k].Error()
+	})
+
+	return errs
+}
+
+//parseOption populates option with values from all middlewares, latest middleware has highest priority
+func (c *Conf) parseOption(optKey OptionKey, opt *Option) error {
+	opt.Put(opt.defaultValue)
+	opt.source = ""
+	opt.encrypted = false
+
+	for _, m := range c.middleware {
+		r, isOk, encrypted, err := c.parseValue(m, optKey, opt)
+
+		if err != nil {
+			return err
+		}
+
+		if !isOk {
+			continue
+		}
+
+		source := middlewareSource(m, optKey)
+
+		if opt.optionType == existenceType && r == false {
+			source = opt.source
+		}
+
+		if !opt.put(r, source) {
+			continue
+		}
+
+		opt.encrypted = encrypted
+
+		if _, isDefaults := m.(*Defaults); isDefaults {
+			opt.defaultValue = r
 		}
 	}
 
 	return nil
 }
 
-//ToStruct populates parsed data to pointed struct by comfyname
+//parseValue returns decrypted value of option from middleware and reports whether value was encrypted.
+//Values are decrypted only when decrypter is set, otherwise `ENC[...]` values are used as is
+func (c *Conf) parseValue(m Middleware, optKey OptionKey, opt *Option) (interface{}, bool, bool, error) {
+	r, isOk, err := c.parseRawValue(m, optKey, opt)
+
+	if err != nil || !isOk || c.decrypter == nil {
+		return r, isOk, false, err
+	}
+
+	encrypted := isEncryptedValue(r)
+	r, err = c.decryptValue(m, optKey, r)
+
+	return r, err == nil, encrypted, err
+}
+
+func (c *Conf) parseRawValue(m Middleware, optKey OptionKey, opt *Option) (r interface{}, isOk bool, err error) {
+	if c.isFileRefEnabled(opt) {
+		r, isOk, err = c.parseFileRef(m, optKey, opt)
+
+		if err != nil || isOk {
+			return
+		}
+	}
+
+	switch opt.GetOptionType() {
+	case stringType:
+		r, isOk = m.ParseString(optKey.shortName, optKey.fullName)
+	case boolType:
+		r, isOk = m.ParseBool(optKey.shortName, optKey.fullName)
+	case intType:
+		r, isOk = m.ParseInt(optKey.shortName, optKey.fullName)
+	case existenceType:
+		r, isOk = m.ParseExistence(optKey.shortName, optKey.fullName)
+	case sliceType:
+		r, isOk = m.ParseSlice(optKey.shortName, optKey.fullName)
+	}
+
+	return
+}
+
+//ToStruct populates parsed data to pointed struct by comfyname. Values, that can not be bound to field type,
+//are skipped, use Bind for getting such errors
 func (c *Conf) ToStruct(structure interface{}) {
+	_ = c.Bind(structure)
+}
+
+//Bind populates parsed data to pointed struct by comfyname. Slices of structs are populated from
+//arrays of objects. Other fields are populated, when value can not be bound, and first such error is returned
+func (c *Conf) Bind(structure interface{}) error {
 
 	rt := reflect.TypeOf(structure)
 	rv := reflect.ValueOf(structure)
 
-	if rt.Kind() != reflect.Ptr {
-		return
+	if rt == nil || rt.Kind() != reflect.Ptr {
+		return nil
 	}
 
+	var bindErr error
+
 	v := rt.Elem()
 
 	for i := 0; i < v.NumField(); i++ {
@@ -80,7 +239,9 @@ func (c *Conf) ToStruct(structure interface{}) {
 			v1 := rv.Elem().Field(i)
 
 			if v1.CanAddr() {
-				c.ToStruct(v1.Addr().Interface())
+				if err := c.Bind(v1.Addr().Interface()); err != nil && bindErr == nil {
+					bindErr = err
+				}
 			}
 
 			continue
@@ -92,13 +253,23 @@ func (c *Conf) ToStruct(structure interface{}) {
 			continue
 		}
 
+		defaultValue, hasDefault := f.Tag.Lookup(defaultTagName)
+		isFound := false
+
 		for optKey, opt := range c.options {
 			if c.isCorrectOpt(optKey, name) {
 				kind := f.Type.Kind()
 				field := rv.Elem().Field(i)
+				isFound = true
 
 				if c.isValKindAllowed(opt.optionType, kind) {
-					field.Set(c.getReflectValueOfVarInterface(opt.variable))
+					value := c.getReflectValueOfVarInterface(opt.variable)
+
+					if value.Type().ConvertibleTo(field.Type()) {
+						field.Set(value.Convert(field.Type()))
+					} else if err := bindValue(field, value.Interface()); err != nil && bindErr == nil {
+						bindErr = fmt.Errorf("comfyconf: %s: %v", f.Name, err)
+					}
 				} else if c.isCorrectTypePointer(kind, field, opt.variable) {
 					field.Set(reflect.ValueOf(opt.variable))
 				}
@@ -106,6 +277,111 @@ func (c *Conf) ToStruct(structure interface{}) {
 				break
 			}
 		}
+
+		if !isFound && hasDefault {
+			c.setTagDefault(rv.Elem().Field(i), defaultValue)
+		}
+	}
+
+	return bindErr
+}
+
+//RegisterStruct applies `default` and `secret` tags of struct fields to declared options, that are bound to fields
+//by comfyname. Must be called before Parse, so help, exports, provenance and error messages see defaults and
+//redactions from tags
+func (c *Conf) RegisterStruct(structure interface{}) error {
+	rt := reflect.TypeOf(structure)
+
+	if rt == nil || rt.Kind() != reflect.Ptr || rt.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("comfyconf: pointer to struct is expected, got %T", structure)
+	}
+
+	return c.registerFields(rt.Elem())
+}
+
+func (c *Conf) registerFields(rt reflect.Type) error {
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+
+		if f.Type.Kind() == reflect.Struct {
+			if err := c.registerFields(f.Type); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		name, isOk := f.Tag.Lookup(tagName)
+
+		if !isOk {
+			continue
+		}
+
+		opt := c.Lookup(name)
+
+		if opt == nil {
+			continue
+		}
+
+		if secret, isOk := f.Tag.Lookup(secretTagName); isOk && secret == "true" {
+			opt.SetSecret(true)
+		}
+
+		if raw, isOk := f.Tag.Lookup(defaultTagName); isOk {
+			if err := c.applyTagDefault(opt, raw); err != nil {
+				return fmt.Errorf("comfyconf: %s: %v", f.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+//applyTagDefault replaces option default value by value from struct tag
+func (c *Conf) applyTagDefault(opt *Option, raw string) error {
+	v, isOk := convertString(opt.optionType, raw)
+
+	if !isOk {
+		return fmt.Errorf("invalid default value %q", raw)
+	}
+
+	opt.Put(v)
+	opt.defaultValue = v
+
+	return nil
+}
+
+//setTagDefault sets default value from struct tag to field, that is not bound to any option
+func (c *Conf) setTagDefault(field reflect.Value, raw string) {
+	if !field.CanSet() {
+		return
+	}
+
+	var optType OptionType
+
+	switch field.Kind() {
+	case reflect.String:
+		optType = stringType
+	case reflect.Int:
+		optType = intType
+	case reflect.Bool:
+		optType = boolType
+	case reflect.Slice:
+		optType = sliceType
+	default:
+		return
+	}
+
+	v, isOk := convertString(optType, raw)
+
+	if !isOk {
+		return
+	}
+
+	rv := reflect.ValueOf(v)
+
+	if rv.Type().ConvertibleTo(field.Type()) {
+		field.Set(rv.Convert(field.Type()))
 	}
 }
 
@@ -118,7 +394,7 @@ func (c *Conf) getReflectValueOfVarInterface(variable interface{}) reflect.Value
 }
 
 func (c *Conf) isCorrectTypePointer(kind reflect.Kind, field reflect.Value, variable interface{}) bool {
-	return kind == reflect.Ptr && field.Type().Elem().Kind() == reflect.ValueOf(variable).Elem().Kind()
+	return kind == reflect.Ptr && reflect.TypeOf(variable).AssignableTo(field.Type())
 }
 
 func (c *Conf) isValKindAllowed(optType OptionType, kind reflect.Kind) bool {
@@ -225,33 +501,61 @@ func (c *Conf) Slice(shortName string, fullName string, defaultValue []interface
 	return variable
 }
 
+//Lookup returns option by its full or short name, or nil if option not defined
+func (c *Conf) Lookup(name string) *Option {
+	for optKey, opt := range c.options {
+		if optKey.fullName == name {
+			return opt
+		}
+	}
+
+	for optKey, opt := range c.options {
+		if optKey.shortName == name {
+			return opt
+		}
+	}
+
+	return nil
+}
+
+//Explain returns human readable explanation of option value and source, from where value was taken
+func (c *Conf) Explain(name string) (string, bool) {
+	opt := c.Lookup(name)
+
+	if opt == nil {
+		return "", false
+	}
+
+	return opt.String(), true
+}
+
 //PrintHelp created for executing function that will instruction
 func (c *Conf) PrintHelp(printer func(options map[OptionKey]*Option)) {
 	printer(c.options)
 }
 
 func (c *Conf) createOption(shortName string, fullName string, defaultValue interface{}, variable interface{}, optionType OptionType, description string) {
-	c.options[OptionKey{
+	key := OptionKey{
 		shortName,
 		fullName,
-	}] = &Option{
-		defaultValue,
-		variable,
-		optionType,
-		description,
 	}
-}
 
-//DefaultHelpPrinter function for printing help information. Should be passed to Conf.PrintHelp
-func DefaultHelpPrinter(options map[OptionKey]*Option) {
-	var buffer bytes.Buffer
-
-	buffer.WriteString("Usage: app [options] \n")
-	buffer.WriteString("  Options: \n")
+	c.declared++
 
-	for def, opt := range options {
-		buffer.WriteString("    -" + def.GetShort() + ", --" + def.GetFull() + "   " + opt.description + "\n")
+	c.options[key] = &Option{
+		key:          key,
+		index:        c.declared,
+		defaultValue: defaultValue,
+		variable:     variable,
+		optionType:   optionType,
+		description:  description,
 	}
+}
 
-	fmt.Println(buffer.String())
+//DefaultHelpPrinter function for printing help information. Should be passed to Conf.PrintHelp.
+//Printer can not return error, so error of writing usage is printed to standard error output
+func DefaultHelpPrinter(options map[OptionKey]*Option) {
+	if err := (&Conf{options: options}).WriteUsage(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "comfyconf:", err)
+	}
 }
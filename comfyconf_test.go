@@ -126,6 +126,69 @@ func TestConf_ToStruct(t *testing.T) {
 	assert.Equal(t, "JAre", testStruct.TestStruct.Test)
 }
 
+func TestConf_ToStruct_DefaultTag(t *testing.T) {
+
+	var testStruct struct {
+		Timeout  string        `comfyname:"timeout" default:"30s"`
+		Retries  int           `comfyname:"retries" default:"3"`
+		Verbose  bool          `comfyname:"verbose" default:"true"`
+		Hosts    []interface{} `comfyname:"hosts" default:"a,b"`
+		Unbound  int           `comfyname:"unbound" default:"42"`
+		Provided string        `comfyname:"provided" default:"tag"`
+	}
+
+	conf := prepareConf([]string{"--provided=flag"}, "=")
+
+	conf.String("t", "timeout", "", "Timeout")
+	conf.Int("r", "retries", 0, "Retries")
+	conf.Bool("v", "verbose", false, "Verbose")
+	conf.Slice("h", "hosts", nil, "Hosts")
+	conf.String("p", "provided", "", "Provided")
+
+	assert.NoError(t, conf.RegisterStruct(&testStruct))
+	assert.Equal(t, 3, conf.Lookup("retries").GetDefaultValue())
+
+	assert.Nil(t, conf.Parse())
+	conf.ToStruct(&testStruct)
+
+	assert.Equal(t, "30s", testStruct.Timeout)
+	assert.Equal(t, 3, testStruct.Retries)
+	assert.True(t, testStruct.Verbose)
+	assert.Equal(t, []interface{}{"a", "b"}, testStruct.Hosts)
+	assert.Equal(t, 42, testStruct.Unbound)
+	assert.Equal(t, "flag", testStruct.Provided)
+
+	assert.Equal(t, 3, conf.Lookup("retries").GetDefaultValue())
+	assert.Equal(t, SourceDefault, conf.Lookup("retries").GetSource())
+}
+
+func TestConf_RegisterStruct_InvalidDefault(t *testing.T) {
+	var testStruct struct {
+		Retries int `comfyname:"retries" default:"many"`
+	}
+
+	conf := prepareConf([]string{}, "=")
+	conf.Int("r", "retries", 0, "Retries")
+
+	assert.EqualError(t, conf.RegisterStruct(&testStruct), `comfyconf: Retries: invalid default value "many"`)
+	assert.EqualError(t, conf.RegisterStruct(42), "comfyconf: pointer to struct is expected, got int")
+}
+
+func TestConf_Explain(t *testing.T) {
+
+	conf := prepareConf([]string{"--test=mofa"}, "=")
+	conf.String("t", "test", "ews", "Basic description")
+
+	assert.Nil(t, conf.Parse())
+
+	explanation, isOk := conf.Explain("t")
+	assert.True(t, isOk)
+	assert.Equal(t, `test = "mofa" (source: flags)`, explanation)
+
+	_, isOk = conf.Explain("unknown")
+	assert.False(t, isOk)
+}
+
 func TestConf_PrintHelp(t *testing.T) {
 
 	conf := prepareConf([]string{"--Ayangar=FireGM", "-0sk0L0k=Kergan"}, "=")
@@ -150,3 +213,46 @@ func TestDefaultHelpPrinter(t *testing.T) {
 
 	conf.PrintHelp(DefaultHelpPrinter)
 }
+
+func TestConf_ToStruct_SliceOfStructs(t *testing.T) {
+	type Server struct {
+		Host    string
+		Port    int `comfyname:"port"`
+		Tags    []string
+		Weight  float64
+		Primary *bool
+	}
+
+	type Config struct {
+		Servers []Server `comfyname:"servers"`
+		Ports   []int    `comfyname:"ports"`
+	}
+
+	conf := New(NewJSONWithCustomReader(func(j *JSON) ([]byte, error) {
+		return []byte(`{"servers": [{"host": "a.local", "port": 80, "tags": ["x"], "weight": 0.5, "primary": true},
+			{"Host": "b.local", "port": "8080"}], "ports": [1, 2]}`), nil
+	}))
+
+	conf.Slice("", "servers", nil, "Servers")
+	conf.Slice("", "ports", nil, "Ports")
+
+	assert.NoError(t, conf.Parse())
+
+	var config Config
+	assert.NoError(t, conf.Bind(&config))
+
+	primary := true
+	assert.Equal(t, []Server{
+		{Host: "a.local", Port: 80, Tags: []string{"x"}, Weight: 0.5, Primary: &primary},
+		{Host: "b.local", Port: 8080},
+	}, config.Servers)
+	assert.Equal(t, []int{1, 2}, config.Ports)
+
+	conf = New(NewJSONWithCustomReader(func(j *JSON) ([]byte, error) {
+		return []byte(`{"servers": [{"port": "http"}]}`), nil
+	}))
+	conf.Slice("", "servers", nil, "Servers")
+
+	assert.NoError(t, conf.Parse())
+	assert.EqualError(t, conf.Bind(&config), "comfyconf: Servers: 0: Port: can not bind string to int")
+}
@@ -0,0 +1,498 @@
+package comfyconf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//Shell shell, that completion script is generated for
+type Shell string
+
+const (
+	//Bash completion script for bash, source it from `~/.bashrc` or install into `bash-completion` directory
+	Bash Shell = "bash"
+	//Zsh completion script for zsh, install it as `_<program>` file into `$fpath`
+	Zsh Shell = "zsh"
+	//Fish completion script for fish, install it into `~/.config/fish/completions/<program>.fish`
+	Fish Shell = "fish"
+	//PowerShell completion script for PowerShell, source it from `$PROFILE`
+	PowerShell Shell = "powershell"
+)
+
+//completeCommand hidden command, that prints completion candidates of last argument
+const completeCommand = "__complete"
+
+//ValueHint describes kind of option value for shell completion
+type ValueHint int
+
+const (
+	//HintNone value is not completed, unless option has enum values or completer
+	HintNone ValueHint = iota
+	//HintFile value is completed with file paths
+	HintFile
+	//HintDirectory value is completed with directory paths
+	HintDirectory
+)
+
+var shellFunctionExpr = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+//SetValueHint sets kind of option value for shell completion
+func (o *Option) SetValueHint(hint ValueHint) {
+	o.hint = hint
+}
+
+//SetCompleter sets function, that returns dynamic completion candidates of option value.
+//Completer is called by hidden `__complete` command of program, so candidates can depend on runtime state
+func (o *Option) SetCompleter(completer func(prefix string) []string) {
+	o.completer = completer
+}
+
+//SetValueHint sets kind of value for shell completion of options with provided full or short names
+func (c *Conf) SetValueHint(hint ValueHint, names ...string) {
+	for _, name := range names {
+		if opt := c.Lookup(name); opt != nil {
+			opt.SetValueHint(hint)
+		}
+	}
+}
+
+//WriteCompletion writes completion script for shell. Script completes option flags, subcommands added by
+//AddCommand and values of options: file paths, enum values from OneOf validators and dynamic values,
+//that are requested from program itself by `<program> __complete <word>`
+func (c *Conf) WriteCompletion(w io.Writer, shell Shell) error {
+	buffer := bufio.NewWriter(w)
+
+	switch shell {
+	case Bash:
+		c.writeBashCompletion(buffer)
+	case Zsh:
+		c.writeZshCompletion(buffer)
+	case Fish:
+		c.writeFishCompletion(buffer)
+	case PowerShell:
+		c.writePowerShellCompletion(buffer)
+	default:
+		return fmt.Errorf("comfyconf: unsupported shell %q", shell)
+	}
+
+	return buffer.Flush()
+}
+
+func (c *Conf) writeBashCompletion(w io.Writer) {
+	name := c.programName()
+	function := "_" + shellFunctionExpr.ReplaceAllString(name, "_") + "_completion"
+
+	flags := make([]string, 0)
+
+	for _, opt := range c.renderedOptions() {
+		flags = append(flags, opt.completionFlags()...)
+	}
+
+	fmt.Fprintf(w, "# bash completion for %s, generated by comfyconf\n\n", name)
+	fmt.Fprintf(w, "%s() {\n", function)
+	fmt.Fprintln(w, `    local line="${COMP_LINE:0:COMP_POINT}"`)
+	fmt.Fprintln(w, `    local cur="${line##*[[:space:]]}"`)
+	fmt.Fprintf(w, "    local flags=%s\n", shellQuote(strings.Join(flags, " ")))
+	fmt.Fprintf(w, "    local commands=%s\n\n", shellQuote(strings.Join(c.commandNames(), " ")))
+	fmt.Fprintln(w, "    COMPREPLY=()")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `    if [[ "$cur" == -*=* ]]; then`)
+	fmt.Fprintln(w, `        local name="${cur%%=*}" value="${cur#*=}"`)
+	fmt.Fprintln(w, `        case "$name" in`)
+
+	for _, opt := range c.renderedOptions() {
+		var reply string
+
+		switch {
+		case opt.completer != nil:
+			reply = fmt.Sprintf(`compgen -W "$(%s %s "$cur" 2>/dev/null)" -- "$value"`, shellQuote(name), completeCommand)
+		case len(opt.enumValues()) != 0:
+			reply = fmt.Sprintf(`compgen -W %s -- "$value"`, shellQuote(strings.Join(opt.enumValues(), " ")))
+		case opt.hint == HintFile:
+			reply = `compgen -f -- "$value"`
+		case opt.hint == HintDirectory:
+			reply = `compgen -d -- "$value"`
+		default:
+			continue
+		}
+
+		fmt.Fprintf(w, "            %s)\n", strings.Join(opt.completionNames(), "|"))
+		fmt.Fprintf(w, "                COMPREPLY=($(%s)) ;;\n", reply)
+	}
+
+	fmt.Fprintln(w, "        esac")
+	fmt.Fprintln(w, "        return 0")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `    if [[ "$cur" == -* ]]; then`)
+	fmt.Fprintln(w, `        COMPREPLY=($(compgen -W "$flags" -- "$cur"))`)
+	fmt.Fprintln(w, `        if [[ ${#COMPREPLY[@]} -eq 1 && "${COMPREPLY[0]}" == *= ]]; then`)
+	fmt.Fprintln(w, "            compopt -o nospace")
+	fmt.Fprintln(w, "        fi")
+	fmt.Fprintln(w, "        return 0")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `    COMPREPLY=($(compgen -W "$commands" -- "$cur"))`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "complete -F %s %s\n", function, shellQuote(name))
+}
+
+func (c *Conf) writeZshCompletion(w io.Writer) {
+	name := c.programName()
+	function := "_" + shellFunctionExpr.ReplaceAllString(name, "_")
+
+	fmt.Fprintf(w, "#compdef %s\n", name)
+	fmt.Fprintf(w, "# zsh completion for %s, generated by comfyconf\n\n", name)
+	fmt.Fprintf(w, "%s() {\n", function)
+
+	if len(c.commands) != 0 {
+		fmt.Fprintln(w, "    local -a commands")
+		fmt.Fprintln(w, "    commands=(")
+
+		for _, command := range c.commands {
+			fmt.Fprintf(w, "        %s\n", shellQuote(zshEscape(command.name, ":")+":"+command.description))
+		}
+
+		fmt.Fprintln(w, "    )")
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "    _arguments -s \\")
+
+	for _, opt := range c.renderedOptions() {
+		fmt.Fprintf(w, "        %s \\\n", c.zshOptionSpec(opt))
+	}
+
+	if len(c.commands) != 0 {
+		fmt.Fprintln(w, `        '1: :{_describe command commands}' \`)
+	}
+
+	fmt.Fprintln(w, `        '*:: :_default'`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "if [ \"$funcstack[1]\" = %s ]; then\n", shellQuote(function))
+	fmt.Fprintf(w, "    %s \"$@\"\n", function)
+	fmt.Fprintln(w, "else")
+	fmt.Fprintf(w, "    compdef %s %s\n", function, shellQuote(name))
+	fmt.Fprintln(w, "fi")
+}
+
+//zshOptionSpec returns _arguments specification of option, like `'(-p --port)'{-p=,--port=}'[Port]:int:'`
+func (c *Conf) zshOptionSpec(opt *Option) string {
+	flags := opt.completionFlags()
+	names := make([]string, 0, len(flags))
+
+	for _, flag := range flags {
+		names = append(names, shellQuote(zshEscape(flag, "[]:")))
+	}
+
+	spec := "[" + zshEscape(opt.description, "[]") + "]"
+
+	if opt.optionType != existenceType {
+		message := zshEscape(opt.optionType.String(), ":")
+		action := ""
+
+		switch {
+		case opt.completer != nil:
+			action = fmt.Sprintf(`{compadd -- ${(f)"$(%s %s %s 2>/dev/null)"}}`,
+				shellQuote(c.programName()), completeCommand, shellQuote(flags[len(flags)-1]))
+		case len(opt.enumValues()) != 0:
+			values := make([]string, 0)
+
+			for _, value := range opt.enumValues() {
+				values = append(values, zshEscape(value, " ()"))
+			}
+
+			action = "(" + strings.Join(values, " ") + ")"
+		case opt.hint == HintFile:
+			action = "_files"
+		case opt.hint == HintDirectory:
+			action = "_files -/"
+		}
+
+		spec += ":" + message + ":" + action
+	}
+
+	if len(names) == 1 {
+		return names[0] + shellQuote(spec)
+	}
+
+	return shellQuote("("+strings.Join(opt.completionNames(), " ")+")") + "{" + strings.Join(names, ",") + "}" + shellQuote(spec)
+}
+
+func (c *Conf) writeFishCompletion(w io.Writer) {
+	name := c.programName()
+
+	fmt.Fprintf(w, "# fish completion for %s, generated by comfyconf\n\n", name)
+	fmt.Fprintf(w, "complete -c %s -f\n", fishQuote(name))
+
+	for _, command := range c.commands {
+		fmt.Fprintf(w, "complete -c %s -n __fish_use_subcommand -a %s -d %s\n",
+			fishQuote(name), fishQuote(command.name), fishQuote(command.description))
+	}
+
+	for _, opt := range c.renderedOptions() {
+		line := "complete -c " + fishQuote(name)
+
+		if short := opt.key.shortName; len(short) == 1 {
+			line += " -s " + fishQuote(short)
+		} else if len(short) != 0 {
+			line += " -o " + fishQuote(short)
+		}
+
+		if full := opt.key.fullName; len(full) != 0 {
+			if opt.optionType == sliceType {
+				full += "[]"
+			}
+
+			line += " -l " + fishQuote(full)
+		}
+
+		if len(opt.description) != 0 {
+			line += " -d " + fishQuote(opt.description)
+		}
+
+		flags := opt.completionFlags()
+
+		switch {
+		case opt.optionType == existenceType:
+		case opt.completer != nil:
+			line += " -xa " + fishQuote(fmt.Sprintf("(%s %s %s)", fishQuote(name), completeCommand, fishQuote(flags[len(flags)-1])))
+		case len(opt.enumValues()) != 0:
+			line += " -xa " + fishQuote(strings.Join(opt.enumValues(), " "))
+		case opt.hint == HintFile:
+			line += " -rF"
+		case opt.hint == HintDirectory:
+			line += " -xa '(__fish_complete_directories)'"
+		default:
+			line += " -x"
+		}
+
+		fmt.Fprintln(w, line)
+	}
+}
+
+func (c *Conf) writePowerShellCompletion(w io.Writer) {
+	name := c.programName()
+
+	fmt.Fprintf(w, "# PowerShell completion for %s, generated by comfyconf\n\n", name)
+	fmt.Fprintf(w, "Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n", powerShellQuote(name))
+	fmt.Fprintln(w, "    param($wordToComplete, $commandAst, $cursorPosition)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "    $candidates = @(")
+
+	for _, opt := range c.renderedOptions() {
+		for _, flag := range opt.completionFlags() {
+			fmt.Fprintf(w, "        @(%s, %s)\n", powerShellQuote(flag), powerShellQuote(opt.description))
+		}
+	}
+
+	for _, command := range c.commands {
+		fmt.Fprintf(w, "        @(%s, %s)\n", powerShellQuote(command.name), powerShellQuote(command.description))
+	}
+
+	fmt.Fprintln(w, "    )")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "    if ($wordToComplete -match '^(-[^=]*=)') {")
+	fmt.Fprintln(w, "        $prefix = $Matches[1]")
+	fmt.Fprintf(w, "        $candidates = & %s %s \"$wordToComplete\" 2>$null | ForEach-Object { ,@(($prefix + $_), $_) }\n",
+		powerShellQuote(name), completeCommand)
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "    $candidates | Where-Object { $_[0] -like \"$wordToComplete*\" } | ForEach-Object {")
+	fmt.Fprintln(w, "        $description = if ($_[1]) { $_[1] } else { $_[0] }")
+	fmt.Fprintln(w, "        [System.Management.Automation.CompletionResult]::new($_[0], $_[0], 'ParameterValue', $description)")
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintln(w, "}")
+}
+
+//applyComplete handles hidden `__complete` command, that prints candidates for last argument
+func (c *Conf) applyComplete() error {
+	for _, m := range c.middleware {
+		flags, isOk := m.(*Flags)
+
+		if !isOk || len(flags.args) < 2 || flags.args[1] != completeCommand {
+			continue
+		}
+
+		word := ""
+
+		if len(flags.args) > 2 {
+			word = flags.args[len(flags.args)-1]
+		}
+
+		for _, candidate := range c.complete(word) {
+			if _, err := fmt.Fprintln(c.out(), candidate); err != nil {
+				return err
+			}
+		}
+
+		return ErrComplete
+	}
+
+	return nil
+}
+
+//complete returns completion candidates for word. Flags are completed for words starting with dash,
+//values are completed for words like `--name=value` and subcommands are completed for other words.
+//Candidates of values do not include flag prefix
+func (c *Conf) complete(word string) []string {
+	if !strings.HasPrefix(word, "-") {
+		return filterPrefix(c.commandNames(), word)
+	}
+
+	i := strings.Index(word, "=")
+
+	if i < 0 {
+		flags := make([]string, 0)
+
+		for _, opt := range c.renderedOptions() {
+			flags = append(flags, opt.completionFlags()...)
+		}
+
+		return filterPrefix(flags, word)
+	}
+
+	name := strings.TrimLeft(word[:i], "-")
+
+	if j := strings.Index(name, "["); j >= 0 {
+		name = name[:j]
+	}
+
+	opt := c.Lookup(name)
+
+	if opt == nil {
+		return nil
+	}
+
+	return opt.completeValue(word[i+1:])
+}
+
+//completeValue returns completion candidates of option value
+func (o *Option) completeValue(prefix string) []string {
+	switch {
+	case o.completer != nil:
+		return filterPrefix(o.completer(prefix), prefix)
+	case len(o.enumValues()) != 0:
+		return filterPrefix(o.enumValues(), prefix)
+	case o.hint == HintFile || o.hint == HintDirectory:
+		return completePath(prefix, o.hint == HintDirectory)
+	}
+
+	return nil
+}
+
+//completePath returns paths, that start with prefix, directories have trailing separator
+func completePath(prefix string, onlyDirectories bool) []string {
+	matches, _ := filepath.Glob(prefix + "*")
+	paths := make([]string, 0, len(matches))
+
+	for _, match := range matches {
+		info, err := os.Stat(match)
+
+		if err != nil {
+			continue
+		}
+
+		if info.IsDir() {
+			paths = append(paths, match+string(filepath.Separator))
+		} else if !onlyDirectories {
+			paths = append(paths, match)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+//completionFlags returns flags of option, like `-p=` and `--port=`. Existence flags have no assignment
+func (o *Option) completionFlags() []string {
+	suffix := "="
+
+	switch o.optionType {
+	case existenceType:
+		suffix = ""
+	case sliceType:
+		suffix = "[]="
+	}
+
+	flags := make([]string, 0, 2)
+
+	for _, name := range o.completionNames() {
+		flags = append(flags, name+suffix)
+	}
+
+	return flags
+}
+
+//completionNames returns dashed names of option, like `-p` and `--port`
+func (o *Option) completionNames() []string {
+	names := make([]string, 0, 2)
+
+	if len(o.key.shortName) != 0 {
+		names = append(names, "-"+o.key.shortName)
+	}
+
+	if len(o.key.fullName) != 0 {
+		names = append(names, "--"+o.key.fullName)
+	}
+
+	return names
+}
+
+func (c *Conf) commandNames() []string {
+	names := make([]string, 0, len(c.commands))
+
+	for _, command := range c.commands {
+		names = append(names, command.name)
+	}
+
+	return names
+}
+
+func filterPrefix(values []string, prefix string) []string {
+	filtered := make([]string, 0, len(values))
+
+	for _, value := range values {
+		if strings.HasPrefix(value, prefix) {
+			filtered = append(filtered, value)
+		}
+	}
+
+	return filtered
+}
+
+//shellQuote returns single quoted string for bash and zsh
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+//zshEscape escapes characters, that have special meaning in _arguments specification
+func zshEscape(s string, chars string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+
+	for _, char := range chars {
+		s = strings.Replace(s, string(char), `\`+string(char), -1)
+	}
+
+	return s
+}
+
+//fishQuote returns single quoted string for fish
+func fishQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return "'" + strings.Replace(s, "'", `\'`, -1) + "'"
+}
+
+//powerShellQuote returns single quoted string for PowerShell
+func powerShellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
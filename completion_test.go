@@ -0,0 +1,123 @@
+package comfyconf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func prepareCompletionConf(args []string) *Conf {
+	conf := prepareConf(args, "=")
+	conf.SetProgram("server", "")
+
+	conf.String("l", "level", "info", "Log level")
+	conf.AddValidator("level", OneOf("debug", "info"))
+	conf.String("c", "config", "", "Config file")
+	conf.SetValueHint(HintFile, "config")
+	conf.String("", "region", "", "Region [cloud]")
+	conf.Lookup("region").SetCompleter(func(prefix string) []string {
+		return []string{"eu-west", "us-east"}
+	})
+	conf.Slice("t", "tags", nil, "Tags")
+	conf.Exist("v", "verbose", "Verbose output")
+	conf.AddCommand("serve", "Start server")
+	conf.AddCommand("migrate", "Run migrations")
+
+	return conf
+}
+
+func TestConf_WriteCompletion_Bash(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, prepareCompletionConf(nil).WriteCompletion(&buffer, Bash))
+
+	script := buffer.String()
+
+	assert.Contains(t, script, "_server_completion() {\n")
+	assert.Contains(t, script, "local flags='-l= --level= -c= --config= --region= -t[]= --tags[]= -v --verbose -h --help'\n")
+	assert.Contains(t, script, "local commands='serve migrate'\n")
+	assert.Contains(t, script, "-l|--level)\n                COMPREPLY=($(compgen -W 'debug info' -- \"$value\")) ;;\n")
+	assert.Contains(t, script, "-c|--config)\n                COMPREPLY=($(compgen -f -- \"$value\")) ;;\n")
+	assert.Contains(t, script, `COMPREPLY=($(compgen -W "$('server' __complete "$cur" 2>/dev/null)" -- "$value")) ;;`)
+	assert.Contains(t, script, "complete -F _server_completion 'server'\n")
+}
+
+func TestConf_WriteCompletion_Zsh(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, prepareCompletionConf(nil).WriteCompletion(&buffer, Zsh))
+
+	script := buffer.String()
+
+	assert.Contains(t, script, "#compdef server\n")
+	assert.Contains(t, script, `'(-l --level)'{'-l=','--level='}'[Log level]:string:(debug info)' \`)
+	assert.Contains(t, script, `'(-c --config)'{'-c=','--config='}'[Config file]:string:_files' \`)
+	assert.Contains(t, script, `'--region='`+`'[Region \[cloud\]]:string:{compadd -- ${(f)"$('\''server'\'' __complete '\''--region='\'' 2>/dev/null)"}}' \`)
+	assert.Contains(t, script, `'(-t --tags)'{'-t\[\]=','--tags\[\]='}'[Tags]:slice:' \`)
+	assert.Contains(t, script, `'(-v --verbose)'{'-v','--verbose'}'[Verbose output]' \`)
+	assert.Contains(t, script, "        'serve:Start server'\n")
+}
+
+func TestConf_WriteCompletion_Fish(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, prepareCompletionConf(nil).WriteCompletion(&buffer, Fish))
+
+	script := buffer.String()
+
+	assert.Contains(t, script, "complete -c 'server' -n __fish_use_subcommand -a 'serve' -d 'Start server'\n")
+	assert.Contains(t, script, "complete -c 'server' -s 'l' -l 'level' -d 'Log level' -xa 'debug info'\n")
+	assert.Contains(t, script, "complete -c 'server' -s 'c' -l 'config' -d 'Config file' -rF\n")
+	assert.Contains(t, script, `complete -c 'server' -l 'region' -d 'Region [cloud]' -xa '(\'server\' __complete \'--region=\')'`)
+	assert.Contains(t, script, "complete -c 'server' -s 'v' -l 'verbose' -d 'Verbose output'\n")
+}
+
+func TestConf_WriteCompletion_PowerShell(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, prepareCompletionConf(nil).WriteCompletion(&buffer, PowerShell))
+
+	script := buffer.String()
+
+	assert.Contains(t, script, "Register-ArgumentCompleter -Native -CommandName 'server' -ScriptBlock {\n")
+	assert.Contains(t, script, "        @('--level=', 'Log level')\n")
+	assert.Contains(t, script, "        @('serve', 'Start server')\n")
+	assert.Contains(t, script, `& 'server' __complete "$wordToComplete"`)
+
+	assert.Error(t, prepareCompletionConf(nil).WriteCompletion(&buffer, Shell("tcsh")))
+}
+
+func TestConf_Parse_Complete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comfyconf")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte("{}"), 0644))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "conf.d"), 0755))
+
+	cases := map[string]string{
+		"--le":                                "--level=\n",
+		"-":                                   "-l=\n--level=\n-c=\n--config=\n--region=\n-t[]=\n--tags[]=\n-v\n--verbose\n-h\n--help\n",
+		"--level=":                            "debug\ninfo\n",
+		"-l=d":                                "debug\n",
+		"--region=us":                         "us-east\n",
+		"--config=" + filepath.Join(dir, "c"): filepath.Join(dir, "conf.d") + "/\n" + filepath.Join(dir, "config.json") + "\n",
+		"--unknown=":                          "",
+		"m":                                   "migrate\n",
+	}
+
+	for word, expected := range cases {
+		var buffer bytes.Buffer
+
+		conf := prepareCompletionConf([]string{"server", "__complete", word})
+		conf.SetOutput(&buffer)
+
+		assert.Equal(t, ErrComplete, conf.Parse(), word)
+		assert.Equal(t, expected, buffer.String(), word)
+	}
+
+	conf := prepareCompletionConf([]string{"server", "__complete", "--le"})
+	conf.DisableBuiltins()
+
+	assert.NoError(t, conf.Parse())
+}
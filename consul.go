@@ -0,0 +1,130 @@
+package comfyconf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//NewConsulClient returns KVClient for Consul HTTP API with address, like `http://127.0.0.1:8500`
+func NewConsulClient(address string) *ConsulClient {
+	return &ConsulClient{
+		address: strings.TrimRight(address, "/"),
+		client:  http.DefaultClient,
+	}
+}
+
+//ConsulClient KVClient, that uses Consul KV HTTP API. Changes are watched with blocking queries
+type ConsulClient struct {
+	address    string
+	token      string
+	datacenter string
+	client     *http.Client
+}
+
+//consulPair item of Consul KV response, value is base64 encoded and is null for folders
+type consulPair struct {
+	Key   string
+	Value []byte
+}
+
+//SetToken sets ACL token, that is sent in `X-Consul-Token` header
+func (c *ConsulClient) SetToken(token string) {
+	c.token = token
+}
+
+//SetDatacenter sets datacenter, that is queried instead of datacenter of agent
+func (c *ConsulClient) SetDatacenter(datacenter string) {
+	c.datacenter = datacenter
+}
+
+//SetHTTPClient sets HTTP client, that is used for requests. http.DefaultClient is used by default
+func (c *ConsulClient) SetHTTPClient(client *http.Client) {
+	c.client = client
+}
+
+//List returns all pairs with keys starting with prefix and `X-Consul-Index` of response
+func (c *ConsulClient) List(prefix string) ([]KVPair, uint64, error) {
+	pairs, index, err := c.get(context.Background(), prefix, url.Values{})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]KVPair, 0, len(pairs))
+
+	for _, pair := range pairs {
+		result = append(result, KVPair{pair.Key, pair.Value})
+	}
+
+	return result, index, nil
+}
+
+//Wait performs blocking query, that returns when index is changed or wait time is elapsed
+func (c *ConsulClient) Wait(ctx context.Context, prefix string, index uint64, waitTime time.Duration) (uint64, error) {
+	query := url.Values{}
+	query.Set("index", strconv.FormatUint(index, 10))
+	query.Set("wait", fmt.Sprintf("%dms", waitTime/time.Millisecond))
+
+	//Consul adds up to 1/16 of wait time as jitter, so request has additional time to complete
+	ctx, cancel := context.WithTimeout(ctx, waitTime+waitTime/16+10*time.Second)
+	defer cancel()
+
+	_, newIndex, err := c.get(ctx, prefix, query)
+
+	return newIndex, err
+}
+
+func (c *ConsulClient) get(ctx context.Context, prefix string, query url.Values) ([]consulPair, uint64, error) {
+	query.Set("recurse", "true")
+
+	if len(c.datacenter) != 0 {
+		query.Set("dc", c.datacenter)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, c.address+"/v1/kv/"+strings.TrimLeft(prefix, "/")+"?"+query.Encode(), nil)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(c.token) != 0 {
+		request.Header.Set("X-Consul-Token", c.token)
+	}
+
+	response, err := c.client.Do(request.WithContext(ctx))
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotFound {
+		return nil, 0, fmt.Errorf("consul: unexpected status %s", response.Status)
+	}
+
+	index, err := strconv.ParseUint(response.Header.Get("X-Consul-Index"), 10, 64)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("consul: invalid X-Consul-Index %q", response.Header.Get("X-Consul-Index"))
+	}
+
+	//missing prefix has no keys
+	if response.StatusCode == http.StatusNotFound {
+		return nil, index, nil
+	}
+
+	pairs := make([]consulPair, 0)
+
+	if err := json.NewDecoder(response.Body).Decode(&pairs); err != nil {
+		return nil, 0, fmt.Errorf("consul: %v", err)
+	}
+
+	return pairs, index, nil
+}
@@ -0,0 +1,77 @@
+package comfyconf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsulClient(t *testing.T) {
+	index := 7
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Consul-Token"))
+		assert.Equal(t, "true", r.URL.Query().Get("recurse"))
+		assert.Equal(t, "dc2", r.URL.Query().Get("dc"))
+
+		if r.URL.Path == "/v1/kv/missing/" {
+			w.Header().Set("X-Consul-Index", "3")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		assert.Equal(t, "/v1/kv/app/", r.URL.Path)
+
+		if r.URL.Query().Get("index") == "7" {
+			assert.Equal(t, "100ms", r.URL.Query().Get("wait"))
+			index = 8
+		}
+
+		w.Header().Set("X-Consul-Index", strconv.Itoa(index))
+		w.Write([]byte(`[{"Key": "app/", "Value": null}, {"Key": "app/db/port", "Value": "NTQzMg=="}]`))
+	}))
+	defer server.Close()
+
+	c := NewConsulClient(server.URL + "/")
+	c.SetToken("secret")
+	c.SetDatacenter("dc2")
+
+	pairs, listIndex, err := c.List("app/")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), listIndex)
+	assert.Equal(t, []KVPair{{"app/", nil}, {"app/db/port", []byte("5432")}}, pairs)
+
+	waitIndex, err := c.Wait(context.Background(), "app/", 7, 100*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8), waitIndex)
+
+	pairs, listIndex, err = c.List("missing/")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), listIndex)
+	assert.Empty(t, pairs)
+
+	k := NewKV(c, "app/")
+	assert.NoError(t, k.Init())
+
+	port, isOk := k.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 5432, port)
+}
+
+func TestConsulClient_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, _, err := NewConsulClient(server.URL).List("app/")
+	assert.EqualError(t, err, "consul: unexpected status 403 Forbidden")
+
+	err = NewKV(NewConsulClient(server.URL), "app/").Init()
+	assert.EqualError(t, err, `comfyconf: kv "app/": consul: unexpected status 403 Forbidden`)
+}
@@ -0,0 +1,423 @@
+package comfyconf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"filippo.io/age"
+)
+
+const (
+	encryptedPrefix = "ENC["
+	encryptedSuffix = "]"
+
+	//SchemeAESGCM scheme of values, that are encrypted with shared AES-GCM key
+	SchemeAESGCM = "aes-gcm"
+	//SchemeAge scheme of values, that are encrypted to age X25519 recipients. Ciphertext is binary age file,
+	//so value can be decrypted by age tool after base64 decoding
+	SchemeAge = "age"
+)
+
+//ErrUnsupportedScheme is returned by Decrypter, when it can not decrypt values of scheme
+var ErrUnsupportedScheme = errors.New("comfyconf: unsupported encryption scheme")
+
+//Decrypter decrypts values, that are stored in `ENC[scheme:base64]` form. Implementations for key management
+//services can be plugged by implementing this interface
+type Decrypter interface {
+	//Decrypt returns plaintext of ciphertext. Returns ErrUnsupportedScheme, when scheme is not supported
+	Decrypt(scheme string, ciphertext []byte) ([]byte, error)
+}
+
+//Encrypter encrypts values for storing them in `ENC[scheme:base64]` form
+type Encrypter interface {
+	//Encrypt returns scheme and ciphertext of plaintext
+	Encrypt(plaintext []byte) (string, []byte, error)
+}
+
+//Decrypters returns Decrypter, that tries all decrypters in provided order. Used for several schemes
+//or for key rotation, when values are encrypted with different keys
+func Decrypters(decrypters ...Decrypter) Decrypter {
+	return multiDecrypter(decrypters)
+}
+
+type multiDecrypter []Decrypter
+
+func (m multiDecrypter) Decrypt(scheme string, ciphertext []byte) ([]byte, error) {
+	err := ErrUnsupportedScheme
+
+	for _, decrypter := range m {
+		plaintext, decryptErr := decrypter.Decrypt(scheme, ciphertext)
+
+		if decryptErr == nil {
+			return plaintext, nil
+		}
+
+		if decryptErr != ErrUnsupportedScheme {
+			err = decryptErr
+		}
+	}
+
+	return nil, err
+}
+
+//SetDecrypter sets decrypter of `ENC[...]` values of string options and string items of slices.
+//Without decrypter such values are not decrypted and are used as is
+func (c *Conf) SetDecrypter(decrypter Decrypter) {
+	c.decrypter = decrypter
+}
+
+//SetEncrypter sets encrypter, that is used by Persist for values, that were in `ENC[...]` form on latest Parse.
+//Without encrypter Persist fails for such values, so secrets are not written back in plaintext
+func (c *Conf) SetEncrypter(encrypter Encrypter) {
+	c.encrypter = encrypter
+}
+
+//SetDecrypter sets decrypter of encrypted JSON files. File is encrypted, when its whole content is `ENC[...]` value.
+//Included files are decrypted too
+func (j *JSON) SetDecrypter(decrypter Decrypter) {
+	j.decrypter = decrypter
+}
+
+//IsEncrypted reports whether value has `ENC[scheme:base64]` form
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix) && strings.HasSuffix(value, encryptedSuffix) &&
+		strings.Contains(value, ":")
+}
+
+//EncryptValue encrypts value and returns it in `ENC[scheme:base64]` form
+func EncryptValue(encrypter Encrypter, value []byte) (string, error) {
+	scheme, ciphertext, err := encrypter.Encrypt(value)
+
+	if err != nil {
+		return "", err
+	}
+
+	return encryptedPrefix + scheme + ":" + base64.StdEncoding.EncodeToString(ciphertext) + encryptedSuffix, nil
+}
+
+//DecryptValue decrypts value in `ENC[scheme:base64]` form. Values, that are not encrypted, are returned as is
+func DecryptValue(decrypter Decrypter, value string) ([]byte, error) {
+	if !IsEncrypted(value) {
+		return []byte(value), nil
+	}
+
+	if decrypter == nil {
+		return nil, errors.New("value is encrypted, but decrypter is not set")
+	}
+
+	parts := strings.SplitN(strings.TrimSuffix(strings.TrimPrefix(value, encryptedPrefix), encryptedSuffix), ":", 2)
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[1])
+
+	if err != nil {
+		return nil, fmt.Errorf("malformed encrypted value: %v", err)
+	}
+
+	plaintext, err := decrypter.Decrypt(parts[0], ciphertext)
+
+	if err == ErrUnsupportedScheme {
+		return nil, fmt.Errorf("unsupported encryption scheme %q", parts[0])
+	}
+
+	return plaintext, err
+}
+
+//decryptValue decrypts string value or string items of slice from middleware
+func (c *Conf) decryptValue(m Middleware, optKey OptionKey, value interface{}) (interface{}, error) {
+	decrypt := func(s string) (string, error) {
+		plaintext, err := DecryptValue(c.decrypter, s)
+
+		if err != nil {
+			return "", fmt.Errorf("comfyconf: option %q from %s: %v", optKey.fullName, middlewareName(m), err)
+		}
+
+		return string(plaintext), nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		return decrypt(v)
+	case []interface{}:
+		decrypted := make([]interface{}, len(v))
+
+		for i, item := range v {
+			decrypted[i] = item
+
+			if s, isOk := item.(string); isOk {
+				plaintext, err := decrypt(s)
+
+				if err != nil {
+					return nil, err
+				}
+
+				decrypted[i] = plaintext
+			}
+		}
+
+		return decrypted, nil
+	}
+
+	return value, nil
+}
+
+//isEncryptedValue reports whether string value or any string item of slice is in `ENC[...]` form
+func isEncryptedValue(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return IsEncrypted(v)
+	case []interface{}:
+		for _, item := range v {
+			if s, isOk := item.(string); isOk && IsEncrypted(s) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+//encryptValue encrypts string value or string items of slice of option, that was encrypted on latest Parse
+func (c *Conf) encryptValue(opt *Option, value interface{}) (interface{}, error) {
+	if c.encrypter == nil {
+		return nil, fmt.Errorf("comfyconf: option %q is encrypted, but encrypter is not set", opt.key.fullName)
+	}
+
+	encrypt := func(s string) (string, error) {
+		encrypted, err := EncryptValue(c.encrypter, []byte(s))
+
+		if err != nil {
+			return "", fmt.Errorf("comfyconf: option %q: %v", opt.key.fullName, err)
+		}
+
+		return encrypted, nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		return encrypt(v)
+	case []interface{}:
+		encrypted := make([]interface{}, len(v))
+
+		for i, item := range v {
+			encrypted[i] = item
+
+			if s, isOk := item.(string); isOk {
+				ciphertext, err := encrypt(s)
+
+				if err != nil {
+					return nil, err
+				}
+
+				encrypted[i] = ciphertext
+			}
+		}
+
+		return encrypted, nil
+	}
+
+	return value, nil
+}
+
+//decryptFile decrypts content of encrypted JSON file
+func (j *JSON) decryptFile(path string, content []byte) ([]byte, error) {
+	trimmed := strings.TrimSpace(string(content))
+
+	if !IsEncrypted(trimmed) {
+		return content, nil
+	}
+
+	plaintext, err := DecryptValue(j.decrypter, trimmed)
+
+	if err != nil {
+		return nil, fmt.Errorf("comfyconf: %s: %v", path, err)
+	}
+
+	return plaintext, nil
+}
+
+//NewAESGCM returns encrypter and decrypter with shared AES key of 16, 24 or 32 bytes
+func NewAESGCM(key []byte) (*AESGCM, error) {
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, fmt.Errorf("comfyconf: %v", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return nil, fmt.Errorf("comfyconf: %v", err)
+	}
+
+	return &AESGCM{aead}, nil
+}
+
+//ParseAESGCMKey returns AESGCM with base64 encoded key
+func ParseAESGCMKey(key string) (*AESGCM, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+
+	if err != nil {
+		return nil, fmt.Errorf("comfyconf: malformed AES key: %v", err)
+	}
+
+	return NewAESGCM(decoded)
+}
+
+//GenerateAESGCMKey returns random base64 encoded 256-bit AES key
+func GenerateAESGCMKey() (string, error) {
+	key := make([]byte, 32)
+
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+//AESGCM encrypts and decrypts values with shared AES key in GCM mode. Ciphertext is random nonce followed
+//by sealed value
+type AESGCM struct {
+	aead cipher.AEAD
+}
+
+//Encrypt encrypts plaintext with random nonce
+func (a *AESGCM) Encrypt(plaintext []byte) (string, []byte, error) {
+	nonce := make([]byte, a.aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+
+	return SchemeAESGCM, a.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+//Decrypt decrypts values of aes-gcm scheme
+func (a *AESGCM) Decrypt(scheme string, ciphertext []byte) ([]byte, error) {
+	if scheme != SchemeAESGCM {
+		return nil, ErrUnsupportedScheme
+	}
+
+	if len(ciphertext) < a.aead.NonceSize() {
+		return nil, errors.New("malformed encrypted value: ciphertext is too short")
+	}
+
+	nonce, sealed := ciphertext[:a.aead.NonceSize()], ciphertext[a.aead.NonceSize():]
+
+	return a.aead.Open(nil, nonce, sealed, nil)
+}
+
+//GenerateX25519Identity returns new random age X25519 identity
+func GenerateX25519Identity() (*X25519Identity, error) {
+	identity, err := age.GenerateX25519Identity()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &X25519Identity{identity}, nil
+}
+
+//ParseX25519Identity parses age identity in `AGE-SECRET-KEY-1...` form. Comment lines are skipped, so key file
+//of age-keygen or comfyconf-crypt can be used as is
+func ParseX25519Identity(identity string) (*X25519Identity, error) {
+	parsed, err := age.ParseX25519Identity(keyLine(identity))
+
+	if err != nil {
+		return nil, fmt.Errorf("comfyconf: malformed X25519 identity: %v", err)
+	}
+
+	return &X25519Identity{parsed}, nil
+}
+
+//ParseX25519Recipient parses age recipient in `age1...` form
+func ParseX25519Recipient(recipient string) (*X25519Recipient, error) {
+	parsed, err := age.ParseX25519Recipient(keyLine(recipient))
+
+	if err != nil {
+		return nil, fmt.Errorf("comfyconf: malformed X25519 recipient: %v", err)
+	}
+
+	return &X25519Recipient{parsed}, nil
+}
+
+//keyLine returns key without empty lines and comment lines, that start with `#`
+func keyLine(value string) string {
+	lines := make([]string, 0, 1)
+
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+
+		if len(line) != 0 && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "")
+}
+
+//X25519Identity age identity, that decrypts values of age scheme
+type X25519Identity struct {
+	identity *age.X25519Identity
+}
+
+//String returns identity in `AGE-SECRET-KEY-1...` form
+func (i *X25519Identity) String() string {
+	return i.identity.String()
+}
+
+//Recipient returns age recipient of identity, that is used for encryption
+func (i *X25519Identity) Recipient() *X25519Recipient {
+	return &X25519Recipient{i.identity.Recipient()}
+}
+
+//Decrypt decrypts values of age scheme
+func (i *X25519Identity) Decrypt(scheme string, ciphertext []byte) ([]byte, error) {
+	if scheme != SchemeAge {
+		return nil, ErrUnsupportedScheme
+	}
+
+	reader, err := age.Decrypt(bytes.NewReader(ciphertext), i.identity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadAll(reader)
+}
+
+//X25519Recipient age recipient, that encrypts values of age scheme
+type X25519Recipient struct {
+	recipient *age.X25519Recipient
+}
+
+//String returns recipient in `age1...` form
+func (r *X25519Recipient) String() string {
+	return r.recipient.String()
+}
+
+//Encrypt encrypts plaintext to recipient. Ciphertext is binary age file
+func (r *X25519Recipient) Encrypt(plaintext []byte) (string, []byte, error) {
+	var buffer bytes.Buffer
+
+	writer, err := age.Encrypt(&buffer, r.recipient)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	if _, err := writer.Write(plaintext); err != nil {
+		return "", nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", nil, err
+	}
+
+	return SchemeAge, buffer.Bytes(), nil
+}
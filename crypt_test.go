@@ -0,0 +1,180 @@
+package comfyconf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAESGCM(t *testing.T) {
+	key, err := GenerateAESGCMKey()
+	assert.NoError(t, err)
+
+	a, err := ParseAESGCMKey(key)
+	assert.NoError(t, err)
+
+	encrypted, err := EncryptValue(a, []byte("password"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "ENC[aes-gcm:"))
+	assert.True(t, IsEncrypted(encrypted))
+
+	decrypted, err := DecryptValue(a, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "password", string(decrypted))
+
+	other, err := NewAESGCM(make([]byte, 32))
+	assert.NoError(t, err)
+
+	_, err = DecryptValue(other, encrypted)
+	assert.EqualError(t, err, "cipher: message authentication failed")
+
+	_, err = NewAESGCM([]byte("short"))
+	assert.Error(t, err)
+
+	decrypted, err = DecryptValue(a, "plain")
+	assert.NoError(t, err)
+	assert.Equal(t, "plain", string(decrypted))
+}
+
+func TestX25519(t *testing.T) {
+	identity, err := GenerateX25519Identity()
+	assert.NoError(t, err)
+
+	parsedIdentity, err := ParseX25519Identity("# created: 2024-01-02T03:04:05Z\n# public key: " +
+		identity.Recipient().String() + "\n" + identity.String() + "\n")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(identity.String(), "AGE-SECRET-KEY-1"))
+	assert.True(t, strings.HasPrefix(identity.Recipient().String(), "age1"))
+
+	recipient, err := ParseX25519Recipient(identity.Recipient().String())
+	assert.NoError(t, err)
+
+	encrypted, err := EncryptValue(recipient, []byte("token"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "ENC[age:"))
+
+	decrypted, err := DecryptValue(parsedIdentity, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "token", string(decrypted))
+
+	//ciphertext is age file, that is decrypted by age with same identity
+	ageIdentity, err := age.ParseX25519Identity(identity.String())
+	assert.NoError(t, err)
+
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(encrypted, "ENC[age:"), "]"))
+	assert.NoError(t, err)
+
+	reader, err := age.Decrypt(bytes.NewReader(ciphertext), ageIdentity)
+	assert.NoError(t, err)
+
+	plaintext, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "token", string(plaintext))
+
+	other, _ := GenerateX25519Identity()
+	_, err = DecryptValue(other, encrypted)
+	assert.Error(t, err)
+
+	_, err = DecryptValue(parsedIdentity, "ENC[aes-gcm:AAAA]")
+	assert.EqualError(t, err, `unsupported encryption scheme "aes-gcm"`)
+
+	_, err = ParseX25519Identity(identity.Recipient().String())
+	assert.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "comfyconf: malformed X25519 identity: "))
+
+	_, err = ParseX25519Recipient(identity.String())
+	assert.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "comfyconf: malformed X25519 recipient: "))
+}
+
+func TestDecrypters(t *testing.T) {
+	oldKey, _ := NewAESGCM(make([]byte, 32))
+	newKey, _ := NewAESGCM([]byte("0123456789abcdef0123456789abcdef"))
+	identity, _ := GenerateX25519Identity()
+
+	decrypter := Decrypters(newKey, oldKey, identity)
+
+	for _, encrypter := range []Encrypter{oldKey, newKey, identity.Recipient()} {
+		encrypted, err := EncryptValue(encrypter, []byte("value"))
+		assert.NoError(t, err)
+
+		decrypted, err := DecryptValue(decrypter, encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, "value", string(decrypted))
+	}
+}
+
+func TestConf_Decrypt(t *testing.T) {
+	a, _ := NewAESGCM(make([]byte, 32))
+	password, _ := EncryptValue(a, []byte("secret"))
+	token, _ := EncryptValue(a, []byte("token"))
+
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json": `{"db": {"password": "` + password + `"}, "tokens": ["plain", "` + token + `"]}`,
+	})
+	defer os.RemoveAll(dir)
+
+	conf := New(NewJSON(filepath.Join(dir, "config.json")))
+	dbPassword := conf.Secret("", "db.password", "", "Password")
+	tokens := conf.Slice("", "tokens", nil, "Tokens")
+
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, password, dbPassword.Reveal())
+	assert.Equal(t, []interface{}{"plain", token}, *tokens)
+
+	conf.SetDecrypter(a)
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, "secret", dbPassword.Reveal())
+	assert.Equal(t, []interface{}{"plain", "token"}, *tokens)
+}
+
+func TestConf_Decrypt_WithoutDecrypter(t *testing.T) {
+	_ = os.Setenv("APP_NOTE", "ENC[foo:YmFy]")
+	defer os.Unsetenv("APP_NOTE")
+
+	conf := New(NewEnvWithPrefix("APP_"))
+	note := conf.String("", "NOTE", "", "Note")
+
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, "ENC[foo:YmFy]", *note)
+
+	conf.SetDecrypter(Decrypters())
+	assert.EqualError(t, conf.Parse(), `comfyconf: option "NOTE" from env: unsupported encryption scheme "foo"`)
+}
+
+func TestJSON_DecryptFile(t *testing.T) {
+	identity, _ := GenerateX25519Identity()
+	base, _ := EncryptValue(identity.Recipient(), []byte(`{"db": {"host": "localhost", "port": 5432}}`))
+	config, _ := EncryptValue(identity.Recipient(), []byte(`{"$include": "base.json", "db": {"port": 6543}}`))
+
+	dir := prepareJSONFiles(t, map[string]string{
+		"base.json":   base + "\n",
+		"config.json": config + "\n",
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+
+	j := NewJSON(path)
+	assert.Error(t, j.Init())
+
+	j.SetDecrypter(identity)
+	assert.NoError(t, j.Init())
+
+	host, isOk := j.ParseString("host", "db.host")
+	assert.True(t, isOk)
+	assert.Equal(t, "localhost", host)
+
+	port, isOk := j.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 6543, port)
+
+	assert.EqualError(t, j.Save(map[string]interface{}{"db.port": 1}), "comfyconf: "+path+": encrypted file can not be saved")
+}
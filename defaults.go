@@ -0,0 +1,107 @@
+package comfyconf
+
+import "strings"
+
+//NewDefaults returns pointer to instance of defaults middleware, that provides default values from map.
+//Keys of map are full names of options, nested maps are flattened same way as JSON middleware does
+func NewDefaults(values map[string]interface{}) *Defaults {
+	return &Defaults{
+		values: values,
+	}
+}
+
+//NewDefaultsFromJSON returns pointer to instance of defaults middleware, that provides default values from
+//JSON content. Can be used together with embedded files
+func NewDefaultsFromJSON(content []byte) *Defaults {
+	return &Defaults{
+		JSON: JSON{
+			reader: func(j *JSON) ([]byte, error) {
+				return content, nil
+			},
+		},
+	}
+}
+
+//Defaults structure implements Middleware instance for providing default values as a named source.
+//Values provided by Defaults middleware also replaces option default values, so they are visible in help
+type Defaults struct {
+	JSON
+	values map[string]interface{}
+}
+
+//Name returns name of defaults middleware source
+func (d *Defaults) Name() string {
+	return "defaults"
+}
+
+//Init initializing defaults middleware
+func (d *Defaults) Init() error {
+	if d.reader != nil {
+		return d.JSON.Init()
+	}
+
+	d.parsed = d.parse(d.values)
+	d.origins = make(map[string]valueOrigin)
+	d.prepareIndex()
+
+	return nil
+}
+
+//ParseInt tries to get int from defaults, strings are converted same way as Flags middleware does
+func (d *Defaults) ParseInt(shortName string, fullName string) (int, bool) {
+	if v, isOk := d.convert(intType, shortName, fullName); isOk {
+		return v.(int), true
+	}
+
+	return d.JSON.ParseInt(shortName, fullName)
+}
+
+//ParseBool tries to get bool from defaults, strings are converted same way as Flags middleware does
+func (d *Defaults) ParseBool(shortName string, fullName string) (bool, bool) {
+	if v, isOk := d.convert(boolType, shortName, fullName); isOk {
+		return v.(bool), true
+	}
+
+	return d.JSON.ParseBool(shortName, fullName)
+}
+
+//ParseSlice tries to get slice from defaults, strings are split by comma same way as struct tag defaults are
+func (d *Defaults) ParseSlice(shortName string, fullName string) ([]interface{}, bool) {
+	if v, isOk := d.convert(sliceType, shortName, fullName); isOk {
+		return v.([]interface{}), true
+	}
+
+	return d.JSON.ParseSlice(shortName, fullName)
+}
+
+//ParseExistence defaults middleware never marks option as existing
+func (d *Defaults) ParseExistence(shortName string, fullName string) (bool, bool) {
+	return false, false
+}
+
+func (d *Defaults) convert(optionType OptionType, shortName string, fullName string) (interface{}, bool) {
+	v, isOk := d.get(shortName, fullName)
+
+	if !isOk {
+		return nil, false
+	}
+
+	raw, isOk := v.(string)
+
+	if !isOk {
+		return nil, false
+	}
+
+	return convertString(optionType, raw)
+}
+
+//Origin returns source name of profile specific default values
+func (d *Defaults) Origin(shortName string, fullName string) (string, bool) {
+	origin, isOk := d.JSON.Origin(shortName, fullName)
+
+	if !isOk {
+		return "", false
+	}
+
+	return d.Name() + strings.TrimPrefix(origin, "json:"), true
+}
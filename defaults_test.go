@@ -0,0 +1,87 @@
+package comfyconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaults_Init_Map(t *testing.T) {
+	d := NewDefaults(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432,
+		},
+		"timeout": "30",
+		"debug":   "true",
+		"hosts":   "a,b",
+	})
+
+	assert.NoError(t, d.Init())
+
+	v1, isOk := d.ParseString("host", "db.host")
+	assert.True(t, isOk)
+	assert.Equal(t, "localhost", v1)
+
+	v2, isOk := d.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 5432, v2)
+
+	v3, isOk := d.ParseInt("t", "timeout")
+	assert.True(t, isOk)
+	assert.Equal(t, 30, v3)
+
+	v4, isOk := d.ParseBool("d", "debug")
+	assert.True(t, isOk)
+	assert.True(t, v4)
+
+	_, isOk = d.ParseExistence("d", "debug")
+	assert.False(t, isOk)
+
+	v5, isOk := d.ParseSlice("hs", "hosts")
+	assert.True(t, isOk)
+	assert.Equal(t, []interface{}{"a", "b"}, v5)
+}
+
+func TestDefaults_Conf_Slice(t *testing.T) {
+	conf := New(NewDefaults(map[string]interface{}{"hosts": "a,b"}))
+
+	hosts := conf.Slice("hs", "hosts", []interface{}{}, "Hosts")
+	conf.Parse()
+
+	assert.Equal(t, []interface{}{"a", "b"}, *hosts)
+}
+
+func TestDefaults_Init_JSON(t *testing.T) {
+	d := NewDefaultsFromJSON([]byte(`{"db": {"host": "db.local"}}`))
+
+	assert.NoError(t, d.Init())
+	assert.Equal(t, "defaults", d.Name())
+
+	v, isOk := d.ParseString("host", "db.host")
+	assert.True(t, isOk)
+	assert.Equal(t, "db.local", v)
+}
+
+func TestDefaults_Conf_Provenance(t *testing.T) {
+	conf := New(NewDefaults(map[string]interface{}{
+		"host": "example.com",
+		"port": 8080,
+	}), prepareFlags([]string{"--port=9090"}, "="))
+
+	host := conf.String("h", "host", "localhost", "Host")
+	port := conf.Int("p", "port", 80, "Port")
+
+	assert.NoError(t, conf.Parse())
+
+	assert.Equal(t, "example.com", *host)
+	assert.Equal(t, 9090, *port)
+
+	assert.Equal(t, "example.com", conf.Lookup("host").GetDefaultValue())
+	assert.Equal(t, "defaults", conf.Lookup("host").GetSource())
+	assert.Equal(t, "flags", conf.Lookup("port").GetSource())
+
+	explanation, isOk := conf.Explain("host")
+	assert.True(t, isOk)
+	assert.Equal(t, `host = "example.com" (source: defaults)`, explanation)
+}
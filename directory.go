@@ -0,0 +1,247 @@
+package comfyconf
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//NewDirectory creates middleware, that reads configuration from directory with one file per option.
+//File name is used as option name, nested directories are mapped to dotted names and file content is used as value
+func NewDirectory(path string) *Directory {
+	return &Directory{
+		Flags: Flags{
+			parsed:      make(map[string]string),
+			parsedSlice: make(map[string][]interface{}),
+		},
+		path: path,
+	}
+}
+
+//NewCredentialsDirectory creates directory middleware for systemd credentials directory,
+//that is provided by $CREDENTIALS_DIRECTORY. Middleware provides no values, if directory is not set
+func NewCredentialsDirectory() *Directory {
+	return NewDirectory(os.Getenv("CREDENTIALS_DIRECTORY"))
+}
+
+//Directory structure that implements middleware interface for directory of files, like Docker secrets,
+//Kubernetes ConfigMap and Secret mounts or systemd credentials.
+//Hidden files and directories, including Kubernetes `..data` links, are skipped
+type Directory struct {
+	Flags
+	path string
+	fsys fs.FS
+
+	//mu guards fingerprint, that is compared by watching goroutine while Reload initializes directory again
+	mu          sync.Mutex
+	fingerprint string
+}
+
+//Name returns name of directory middleware source
+func (d *Directory) Name() string {
+	return "directory:" + d.path
+}
+
+//Init reads all files from directory. Init can be called again for reloading changed files
+func (d *Directory) Init() error {
+	d.parsed = make(map[string]string)
+	d.parsedSlice = make(map[string][]interface{})
+
+	if len(d.path) == 0 {
+		return nil
+	}
+
+	files, err := d.walk()
+
+	if err != nil {
+		return err
+	}
+
+	for key, file := range files {
+		content, err := d.readFile(file)
+
+		if err != nil {
+			return fmt.Errorf("comfyconf: directory %q: %v", d.path, err)
+		}
+
+		d.parsed[key] = content
+		d.parsedSlice[key] = splitLines(content)
+	}
+
+	fingerprint, err := d.fingerprintOf(files)
+
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.fingerprint = fingerprint
+	d.mu.Unlock()
+
+	return nil
+}
+
+//Changed reports whether files in directory were changed since latest Init.
+//Kubernetes replaces `..data` link atomically, so all files are changed at once
+func (d *Directory) Changed() (bool, error) {
+	if len(d.path) == 0 {
+		return false, nil
+	}
+
+	files, err := d.walk()
+
+	if err != nil {
+		return false, err
+	}
+
+	fingerprint, err := d.fingerprintOf(files)
+
+	if err != nil {
+		return false, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return fingerprint != d.fingerprint, nil
+}
+
+//splitLines returns non-empty lines of value, that are used as slice items
+func splitLines(content string) []interface{} {
+	lines := make([]interface{}, 0)
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if len(line) != 0 {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+//walk returns files of directory by their dotted keys
+func (d *Directory) walk() (map[string]string, error) {
+	if d.fsys != nil {
+		return d.walkFS()
+	}
+
+	files := make(map[string]string)
+	visited := make(map[string]bool)
+
+	err := d.walkOne(files, visited, d.path, "")
+
+	if err != nil {
+		return nil, fmt.Errorf("comfyconf: directory %q: %v", d.path, err)
+	}
+
+	return files, nil
+}
+
+func (d *Directory) walkOne(files map[string]string, visited map[string]bool, dir string, key string) error {
+	realDir, err := filepath.EvalSymlinks(dir)
+
+	if err != nil {
+		return err
+	}
+
+	if visited[realDir] {
+		return nil
+	}
+
+	visited[realDir] = true
+
+	entries, err := ioutil.ReadDir(dir)
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+
+		//stat follows symlinks, that are used by Kubernetes for every key
+		info, err := os.Stat(path)
+
+		if err != nil {
+			return err
+		}
+
+		name := entry.Name()
+
+		if len(key) != 0 {
+			name = key + "." + name
+		}
+
+		if info.IsDir() {
+			if err := d.walkOne(files, visited, path, name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if info.Mode().IsRegular() {
+			files[name] = path
+		}
+	}
+
+	return nil
+}
+
+func (d *Directory) readFile(path string) (string, error) {
+	if d.fsys != nil {
+		return readFSFileRef(d.fsys, path, DefaultFileRefLimit)
+	}
+
+	return readFileRef(path, DefaultFileRefLimit)
+}
+
+func (d *Directory) stat(path string) (fs.FileInfo, error) {
+	if d.fsys != nil {
+		return fs.Stat(d.fsys, path)
+	}
+
+	return os.Stat(path)
+}
+
+//fingerprintOf calculates fingerprint of files by their names, sizes, modification times and target of `..data` link
+func (d *Directory) fingerprintOf(files map[string]string) (string, error) {
+	keys := make([]string, 0, len(files))
+
+	for key := range files {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	hash := sha256.New()
+
+	if d.fsys == nil {
+		if target, err := os.Readlink(filepath.Join(d.path, "..data")); err == nil {
+			fmt.Fprintf(hash, "..data=%s\n", target)
+		}
+	}
+
+	for _, key := range keys {
+		info, err := d.stat(files[key])
+
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(hash, "%s=%d:%d\n", key, info.Size(), info.ModTime().UnixNano())
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
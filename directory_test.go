@@ -0,0 +1,107 @@
+package comfyconf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//prepareKubernetesDirectory creates directory with same layout as Kubernetes ConfigMap volume
+func prepareKubernetesDirectory(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "comfyconf")
+	assert.NoError(t, err)
+
+	swapKubernetesData(t, dir, "..2020_01_01", files)
+
+	for name := range files {
+		assert.NoError(t, os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)))
+	}
+
+	return dir
+}
+
+func swapKubernetesData(t *testing.T, dir string, version string, files map[string]string) {
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, version), 0755))
+
+	for name, content := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, version, name), []byte(content), 0644))
+	}
+
+	assert.NoError(t, os.Symlink(version, filepath.Join(dir, "..data_tmp")))
+	assert.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+}
+
+func TestDirectory_Init(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comfyconf")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "db"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "db", "port"), []byte("5432\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "hosts"), []byte("a\nb\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".hidden"), []byte("x"), 0644))
+
+	d := NewDirectory(dir)
+	assert.NoError(t, d.Init())
+	assert.Equal(t, "directory:"+dir, d.Name())
+
+	port, isOk := d.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 5432, port)
+
+	hosts, isOk := d.ParseSlice("h", "hosts")
+	assert.True(t, isOk)
+	assert.Equal(t, []interface{}{"a", "b"}, hosts)
+
+	_, isOk = d.ParseString(".hidden", ".hidden")
+	assert.False(t, isOk)
+
+	assert.Error(t, NewDirectory(filepath.Join(dir, "missing")).Init())
+}
+
+func TestDirectory_Kubernetes_Reload(t *testing.T) {
+	dir := prepareKubernetesDirectory(t, map[string]string{"log-level": "info\n"})
+	defer os.RemoveAll(dir)
+
+	conf := New(NewDirectory(dir))
+	level := conf.String("l", "log-level", "warn", "Log level")
+
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, "info", *level)
+
+	stop := make(chan struct{})
+	reloaded := make(chan error, 1)
+
+	conf.Watch(10*time.Millisecond, stop, func(err error) {
+		reloaded <- err
+	})
+	defer close(stop)
+
+	swapKubernetesData(t, dir, "..2020_01_02", map[string]string{"log-level": "debug\n"})
+
+	select {
+	case err := <-reloaded:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("configuration was not reloaded")
+	}
+
+	conf.View(func() {
+		assert.Equal(t, "debug", *level)
+	})
+}
+
+func TestNewCredentialsDirectory(t *testing.T) {
+	_ = os.Unsetenv("CREDENTIALS_DIRECTORY")
+
+	d := NewCredentialsDirectory()
+	assert.NoError(t, d.Init())
+
+	changed, err := d.Changed()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+}
@@ -0,0 +1,211 @@
+package comfyconf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//ErrConfigNotFound is returned by Discovery, when no configuration file exists in search paths
+var ErrConfigNotFound = errors.New("comfyconf: configuration file not found")
+
+//SearchPath directory, where configuration file is searched
+type SearchPath struct {
+	Dir string
+	//Description explains location in discovery reason, like `current directory`
+	Description string
+}
+
+//DefaultSearchPaths returns search paths of application in priority order: `./`, `$XDG_CONFIG_HOME/<app>/`,
+//`~/.<app>/` and `/etc/<app>/`. `~/.config` is used, when XDG_CONFIG_HOME is not set.
+//Paths in home directory are skipped, when home directory is not known
+func DefaultSearchPaths(app string) []SearchPath {
+	paths := []SearchPath{{".", "current directory"}}
+	home, homeErr := os.UserHomeDir()
+
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); len(xdg) != 0 {
+		paths = append(paths, SearchPath{filepath.Join(xdg, app), "$XDG_CONFIG_HOME"})
+	} else if homeErr == nil {
+		paths = append(paths, SearchPath{filepath.Join(home, ".config", app), "XDG config directory"})
+	}
+
+	if homeErr == nil {
+		paths = append(paths, SearchPath{filepath.Join(home, "."+app), "home directory"})
+	}
+
+	return append(paths, SearchPath{filepath.Join("/etc", app), "system directory"})
+}
+
+type discoveryFormat struct {
+	extension   string
+	constructor func(file string) Middleware
+}
+
+//NewDiscovery returns discovery of configuration file of application. File `config` with `.json`, `.yaml`,
+//`.yml` or `.toml` extension is searched in DefaultSearchPaths
+func NewDiscovery(app string) *Discovery {
+	return &Discovery{
+		name:  "config",
+		paths: DefaultSearchPaths(app),
+		formats: []discoveryFormat{
+			{".json", func(file string) Middleware { return NewJSON(file) }},
+			{".yaml", func(file string) Middleware { return NewYAML(file) }},
+			{".yml", func(file string) Middleware { return NewYAML(file) }},
+			{".toml", func(file string) Middleware { return NewTOML(file) }},
+		},
+	}
+}
+
+//Discovery finds configuration file in search paths and creates middleware by file extension.
+//File, that is set by file option, has priority over search paths
+type Discovery struct {
+	name    string
+	paths   []SearchPath
+	formats []discoveryFormat
+
+	fileShortName  string
+	fileFullName   string
+	fileMiddleware []Middleware
+
+	file     string
+	reason   string
+	searched []string
+}
+
+//SetName sets name of configuration file without extension
+func (d *Discovery) SetName(name string) {
+	d.name = name
+}
+
+//SetSearchPaths replaces search paths, paths are searched in provided order
+func (d *Discovery) SetSearchPaths(paths ...SearchPath) {
+	d.paths = paths
+}
+
+//AddFormat adds extension with middleware constructor or replaces constructor of known extension.
+//Extensions are tried in order, in which they were added
+func (d *Discovery) AddFormat(extension string, constructor func(file string) Middleware) {
+	for i, format := range d.formats {
+		if format.extension == extension {
+			d.formats[i].constructor = constructor
+			return
+		}
+	}
+
+	d.formats = append(d.formats, discoveryFormat{extension, constructor})
+}
+
+//SetFileOption sets option, that provides configuration file explicitly, like `--config`. Option is parsed
+//by provided middlewares, latest middleware has highest priority
+func (d *Discovery) SetFileOption(shortName string, fullName string, middlewareList ...Middleware) {
+	d.fileShortName = shortName
+	d.fileFullName = fullName
+	d.fileMiddleware = middlewareList
+}
+
+//File returns configuration file, that was chosen by latest Find
+func (d *Discovery) File() string {
+	return d.file
+}
+
+//Reason explains, why file was chosen or why no file was found by latest Find
+func (d *Discovery) Reason() string {
+	return d.reason
+}
+
+//Searched returns files, that were checked by latest Find, in priority order
+func (d *Discovery) Searched() []string {
+	return d.searched
+}
+
+//Find chooses configuration file and returns middleware for it. Returns ErrConfigNotFound,
+//when file option is not set and no file exists in search paths
+func (d *Discovery) Find() (Middleware, error) {
+	d.file, d.reason, d.searched = "", "", make([]string, 0)
+
+	if file, source, isOk := d.fileFromOption(); isOk {
+		constructor, isOk := d.constructor(file)
+
+		if !isOk {
+			return nil, fmt.Errorf("comfyconf: %s: unsupported extension of configuration file", file)
+		}
+
+		if _, err := os.Stat(file); err != nil {
+			return nil, fmt.Errorf("comfyconf: %v", err)
+		}
+
+		d.file = file
+		d.reason = fmt.Sprintf("%s is set by option %q of %s", file, d.fileFullName, source)
+
+		return constructor(file), nil
+	}
+
+	found := make([]string, 0)
+	var chosen SearchPath
+
+	for _, path := range d.paths {
+		for _, format := range d.formats {
+			file := filepath.Join(path.Dir, d.name+format.extension)
+			d.searched = append(d.searched, file)
+
+			if info, err := os.Stat(file); err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+
+			if len(found) == 0 {
+				chosen = path
+			}
+
+			found = append(found, file)
+		}
+	}
+
+	if len(found) == 0 {
+		d.reason = fmt.Sprintf("no configuration file found, searched: %s", strings.Join(d.searched, ", "))
+		return nil, ErrConfigNotFound
+	}
+
+	d.file = found[0]
+	d.reason = fmt.Sprintf("%s is first existing file of %d searched files, found in %s", d.file, len(d.searched), chosen.Description)
+
+	if len(found) > 1 {
+		d.reason += fmt.Sprintf("; files with lower priority are ignored: %s", strings.Join(found[1:], ", "))
+	}
+
+	constructor, _ := d.constructor(d.file)
+
+	return constructor(d.file), nil
+}
+
+//fileFromOption returns file from file option and name of middleware, that provided it
+func (d *Discovery) fileFromOption() (string, string, bool) {
+	var file, source string
+
+	if len(d.fileShortName) == 0 && len(d.fileFullName) == 0 {
+		return "", "", false
+	}
+
+	for _, middleware := range d.fileMiddleware {
+		if err := middleware.Init(); err != nil {
+			continue
+		}
+
+		if parsedFile, isOk := middleware.ParseString(d.fileShortName, d.fileFullName); isOk && len(parsedFile) != 0 {
+			file, source = parsedFile, middlewareName(middleware)
+		}
+	}
+
+	return file, source, len(file) != 0
+}
+
+func (d *Discovery) constructor(file string) (func(file string) Middleware, bool) {
+	for _, format := range d.formats {
+		if strings.EqualFold(filepath.Ext(file), format.extension) {
+			return format.constructor, true
+		}
+	}
+
+	return nil, false
+}
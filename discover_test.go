@@ -0,0 +1,166 @@
+package comfyconf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscovery_Find(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"xdg/app/config.yaml": "db:\n  port: 5432\n",
+		"etc/app/config.json": `{"db": {"port": 6543}}`,
+		"etc/app/config.toml": "[db]\nport = 7654\n",
+	})
+	defer os.RemoveAll(dir)
+
+	d := NewDiscovery("app")
+	d.SetSearchPaths(
+		SearchPath{filepath.Join(dir, "current"), "current directory"},
+		SearchPath{filepath.Join(dir, "xdg", "app"), "$XDG_CONFIG_HOME"},
+		SearchPath{filepath.Join(dir, "etc", "app"), "system directory"},
+	)
+
+	m, err := d.Find()
+	assert.NoError(t, err)
+
+	file := filepath.Join(dir, "xdg", "app", "config.yaml")
+	assert.Equal(t, file, d.File())
+	assert.Equal(t, "yaml:"+file, middlewareName(m))
+	assert.Len(t, d.Searched(), 12)
+	assert.Equal(t, file+" is first existing file of 12 searched files, found in $XDG_CONFIG_HOME; files with lower "+
+		"priority are ignored: "+filepath.Join(dir, "etc", "app", "config.json")+", "+filepath.Join(dir, "etc", "app", "config.toml"), d.Reason())
+
+	conf := New(m)
+	port := conf.Int("", "db.port", 0, "Port")
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, 5432, *port)
+
+	d.SetName("missing")
+	_, err = d.Find()
+	assert.Equal(t, ErrConfigNotFound, err)
+	assert.Empty(t, d.File())
+	assert.Contains(t, d.Reason(), "no configuration file found, searched: "+filepath.Join(dir, "current", "missing.json"))
+}
+
+func TestDiscovery_FileOption(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"custom.toml": "name = \"custom\"\n",
+		"custom.ini":  "name = custom\n",
+	})
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "custom.toml")
+
+	d := NewDiscovery("app")
+	d.SetSearchPaths()
+	d.SetFileOption("c", "config", prepareFlags([]string{"--config=" + file}, "="))
+
+	m, err := d.Find()
+	assert.NoError(t, err)
+	assert.Equal(t, file, d.File())
+	assert.Equal(t, file+` is set by option "config" of flags`, d.Reason())
+
+	assert.NoError(t, m.Init())
+
+	name, isOk := m.ParseString("n", "name")
+	assert.True(t, isOk)
+	assert.Equal(t, "custom", name)
+
+	d.SetFileOption("c", "config", prepareFlags([]string{"--config=" + filepath.Join(dir, "custom.ini")}, "="))
+	_, err = d.Find()
+	assert.EqualError(t, err, "comfyconf: "+filepath.Join(dir, "custom.ini")+": unsupported extension of configuration file")
+
+	d.AddFormat(".ini", func(file string) Middleware {
+		return NewDefaults(map[string]interface{}{"name": "ini"})
+	})
+
+	m, err = d.Find()
+	assert.NoError(t, err)
+	assert.Equal(t, "defaults", middlewareName(m))
+
+	d.SetFileOption("c", "config", prepareFlags([]string{"--config=" + filepath.Join(dir, "missing.json")}, "="))
+	_, err = d.Find()
+	assert.Error(t, err)
+}
+
+func TestDefaultSearchPaths(t *testing.T) {
+	home, xdg := os.Getenv("HOME"), os.Getenv("XDG_CONFIG_HOME")
+	defer os.Setenv("HOME", home)
+	defer os.Setenv("XDG_CONFIG_HOME", xdg)
+
+	_ = os.Setenv("HOME", "/home/user")
+	_ = os.Setenv("XDG_CONFIG_HOME", "")
+
+	assert.Equal(t, []SearchPath{
+		{".", "current directory"},
+		{"/home/user/.config/app", "XDG config directory"},
+		{"/home/user/.app", "home directory"},
+		{"/etc/app", "system directory"},
+	}, DefaultSearchPaths("app"))
+
+	_ = os.Setenv("XDG_CONFIG_HOME", "/xdg")
+
+	assert.Equal(t, SearchPath{"/xdg/app", "$XDG_CONFIG_HOME"}, DefaultSearchPaths("app")[1])
+}
+
+func TestNewYAML(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.yaml": "$include: [base.json, extra.toml]\ndb:\n  host: yaml\n",
+		"base.json":   `{"db": {"host": "json", "port": 5432}}`,
+		"extra.toml":  "[cache]\nsize = 10\n",
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	j := NewYAML(path)
+	assert.NoError(t, j.Init())
+	assert.Equal(t, "yaml:"+path, j.Name())
+
+	host, isOk := j.ParseString("host", "db.host")
+	assert.True(t, isOk)
+	assert.Equal(t, "yaml", host)
+
+	origin, isOk := j.Origin("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, "yaml:"+filepath.Join(dir, "base.json"), origin)
+
+	size, isOk := j.ParseInt("size", "cache.size")
+	assert.True(t, isOk)
+	assert.Equal(t, 10, size)
+
+	assert.EqualError(t, j.Save(map[string]interface{}{"db.host": "other"}), "comfyconf: saving is not supported for yaml files")
+
+	t.Run("invalid", func(t *testing.T) {
+		invalid := filepath.Join(dir, "invalid.toml")
+		assert.NoError(t, ioutil.WriteFile(invalid, []byte("a = \n"), 0644))
+		assert.EqualError(t, NewTOML(invalid).Init(), "comfyconf: "+invalid+`: toml: line 2 (last key "a"): expected value but found '\n' instead`)
+	})
+
+	t.Run("positions", func(t *testing.T) {
+		converted := filepath.Join(dir, "strict.yaml")
+		assert.NoError(t, ioutil.WriteFile(converted, []byte("$include: base.json\nname: app\n"), 0644))
+
+		strict := NewYAML(converted)
+		strict.SetStrict(true)
+
+		conf := New(strict)
+		conf.Int("", "name", 0, "Name")
+		conf.String("", "db.port", "", "Port")
+
+		err := conf.Parse()
+		assert.IsType(t, StrictErrors{}, err)
+		assert.Equal(t, StrictErrors{
+			&TypeError{Key: "db.port", File: filepath.Join(dir, "base.json"), Line: 1, Column: 33, Expected: "string", Actual: "number"},
+			&TypeError{Key: "name", File: converted, Expected: "int", Actual: "string"},
+		}, err)
+
+		sequence := filepath.Join(dir, "sequence.yaml")
+		assert.NoError(t, ioutil.WriteFile(sequence, []byte("- a\n- b\n"), 0644))
+
+		assert.EqualError(t, NewYAML(sequence).Init(), "comfyconf: "+sequence+": json: cannot unmarshal array into Go value of type map[string]interface {}")
+	})
+}
@@ -0,0 +1,293 @@
+package comfyconf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+//docEntry single subcommand or example of reference documentation
+type docEntry struct {
+	name        string
+	description string
+}
+
+//SetDescription sets program description, that is shown in man page and Markdown reference
+func (c *Conf) SetDescription(description string) {
+	c.description = description
+}
+
+//AddCommand adds subcommand with description to man page and Markdown reference
+func (c *Conf) AddCommand(name string, description string) {
+	c.commands = append(c.commands, docEntry{name, description})
+}
+
+//AddExample adds example command line with description to man page and Markdown reference
+func (c *Conf) AddExample(command string, description string) {
+	c.examples = append(c.examples, docEntry{command, description})
+}
+
+//WriteMan writes reference of program options in roff format, that can be installed as section 1 man page.
+//Options are described same way as in usage: flags, type, default value, environment name and JSON path
+func (c *Conf) WriteMan(w io.Writer) error {
+	buffer := bufio.NewWriter(w)
+	name := c.programName()
+
+	fmt.Fprintf(buffer, ".TH %s 1", roffQuote(strings.ToUpper(name)))
+
+	if len(c.version) != 0 {
+		fmt.Fprintf(buffer, " \"\" %s", roffQuote(name+" "+c.version))
+	}
+
+	fmt.Fprintln(buffer)
+
+	fmt.Fprintln(buffer, ".SH NAME")
+
+	if summary := firstLine(c.description); len(summary) != 0 {
+		fmt.Fprintf(buffer, "%s \\- %s\n", roffEscape(name), roffEscape(summary))
+	} else {
+		fmt.Fprintln(buffer, roffEscape(name))
+	}
+
+	fmt.Fprintln(buffer, ".SH SYNOPSIS")
+	fmt.Fprintf(buffer, ".B %s\n", roffEscape(name))
+	fmt.Fprintln(buffer, roffEscape(c.programSynopsis()))
+
+	if len(c.description) != 0 {
+		fmt.Fprintln(buffer, ".SH DESCRIPTION")
+
+		for i, paragraph := range paragraphs(c.description) {
+			if i != 0 {
+				fmt.Fprintln(buffer, ".PP")
+			}
+
+			fmt.Fprintln(buffer, roffEscape(paragraph))
+		}
+	}
+
+	if groups, grouped := groupOptions(c.renderedOptions()); len(groups) != 0 {
+		fmt.Fprintln(buffer, ".SH OPTIONS")
+
+		for _, group := range groups {
+			if len(group) != 0 {
+				fmt.Fprintf(buffer, ".SS %s\n", roffEscape(group))
+			}
+
+			for _, opt := range grouped[group] {
+				fmt.Fprintln(buffer, ".TP")
+				fmt.Fprintf(buffer, "\\fB%s\\fR\n", roffEscape(opt.usageFlag()))
+
+				if len(opt.description) != 0 {
+					fmt.Fprintln(buffer, roffEscape(opt.description))
+				}
+
+				if def := opt.usageDefault(); len(def) != 0 {
+					fmt.Fprintln(buffer, ".br")
+					fmt.Fprintf(buffer, "Default: %s\n", roffEscape(def))
+				}
+
+				if sources := c.usageSources(opt); len(sources) != 0 {
+					fmt.Fprintln(buffer, ".br")
+					fmt.Fprintln(buffer, roffEscape(sources))
+				}
+			}
+		}
+	}
+
+	if len(c.commands) != 0 {
+		fmt.Fprintln(buffer, ".SH COMMANDS")
+
+		for _, command := range c.commands {
+			fmt.Fprintln(buffer, ".TP")
+			fmt.Fprintf(buffer, "\\fB%s\\fR\n", roffEscape(command.name))
+			fmt.Fprintln(buffer, roffEscape(command.description))
+		}
+	}
+
+	if len(c.examples) != 0 {
+		fmt.Fprintln(buffer, ".SH EXAMPLES")
+
+		for _, example := range c.examples {
+			fmt.Fprintln(buffer, ".PP")
+			fmt.Fprintln(buffer, roffEscape(example.description))
+			fmt.Fprintln(buffer, ".PP")
+			fmt.Fprintln(buffer, ".RS 4")
+			fmt.Fprintln(buffer, ".nf")
+			fmt.Fprintln(buffer, roffEscape(example.name))
+			fmt.Fprintln(buffer, ".fi")
+			fmt.Fprintln(buffer, ".RE")
+		}
+	}
+
+	return buffer.Flush()
+}
+
+//WriteMarkdown writes reference of program options in Markdown format. Every group of options
+//is rendered as separate table with flags, type, default value, environment name and JSON path
+func (c *Conf) WriteMarkdown(w io.Writer) error {
+	buffer := bufio.NewWriter(w)
+
+	fmt.Fprintf(buffer, "# %s\n", c.programName())
+
+	if len(c.version) != 0 {
+		fmt.Fprintf(buffer, "\nVersion: `%s`\n", c.version)
+	}
+
+	if len(c.description) != 0 {
+		fmt.Fprintf(buffer, "\n%s\n", strings.TrimSpace(c.description))
+	}
+
+	fmt.Fprintf(buffer, "\n## Synopsis\n\n```\n%s %s\n```\n", c.programName(), c.programSynopsis())
+
+	if groups, grouped := groupOptions(c.renderedOptions()); len(groups) != 0 {
+		fmt.Fprint(buffer, "\n## Options\n")
+
+		for _, group := range groups {
+			if len(group) != 0 {
+				fmt.Fprintf(buffer, "\n### %s\n", group)
+			}
+
+			fmt.Fprint(buffer, "\n| Option | Type | Default | Environment | JSON | Description |\n")
+			fmt.Fprint(buffer, "|---|---|---|---|---|---|\n")
+
+			for _, opt := range grouped[group] {
+				env, _ := c.optionEnvName(opt)
+				path, _ := c.optionJSONPath(opt)
+
+				fmt.Fprintf(buffer, "| %s | %s | %s | %s | %s | %s |\n",
+					markdownFlags(opt),
+					opt.optionType.String(),
+					markdownCode(opt.usageDefault()),
+					markdownCode(env),
+					markdownCode(path),
+					markdownEscape(opt.description),
+				)
+			}
+		}
+	}
+
+	if len(c.commands) != 0 {
+		fmt.Fprint(buffer, "\n## Commands\n\n| Command | Description |\n|---|---|\n")
+
+		for _, command := range c.commands {
+			fmt.Fprintf(buffer, "| %s | %s |\n", markdownCode(command.name), markdownEscape(command.description))
+		}
+	}
+
+	if len(c.examples) != 0 {
+		fmt.Fprint(buffer, "\n## Examples\n")
+
+		for _, example := range c.examples {
+			fmt.Fprintf(buffer, "\n%s\n\n```\n%s\n```\n", strings.TrimSpace(example.description), example.name)
+		}
+	}
+
+	return buffer.Flush()
+}
+
+//renderedOptions returns options in declaration order together with built-in options, that are registered by Parse.
+//Docs and completion are rendered without changing options of Conf
+func (c *Conf) renderedOptions() []*Option {
+	options := c.sortedOptions()
+
+	return append(options, c.pendingBuiltins()...)
+}
+
+//groupOptions returns option groups in usage order and options of every group in declaration order
+func groupOptions(options []*Option) ([]string, map[string][]*Option) {
+	groups := make([]string, 0)
+	grouped := make(map[string][]*Option)
+
+	for _, opt := range options {
+		if _, isExist := grouped[opt.group]; !isExist {
+			groups = append(groups, opt.group)
+		}
+
+		grouped[opt.group] = append(grouped[opt.group], opt)
+	}
+
+	//options without group are shown first
+	sort.SliceStable(groups, func(i, k int) bool {
+		return groups[i] == "" && groups[k] != ""
+	})
+
+	return groups, grouped
+}
+
+//roffEscape escapes text for roff, backslashes and dashes are escaped and control characters
+//at line start are protected
+func roffEscape(text string) string {
+	text = strings.Replace(text, "\\", "\\e", -1)
+	text = strings.Replace(text, "-", "\\-", -1)
+
+	lines := strings.Split(text, "\n")
+
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = "\\&" + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+//roffQuote returns escaped and quoted roff macro argument
+func roffQuote(text string) string {
+	return "\"" + strings.Replace(roffEscape(text), "\"", "\\(dq", -1) + "\""
+}
+
+//markdownFlags returns option flags as inline code, like "`-p`, `--port`"
+func markdownFlags(opt *Option) string {
+	names := make([]string, 0, 2)
+
+	if len(opt.key.shortName) != 0 {
+		names = append(names, markdownCode("-"+opt.key.shortName))
+	}
+
+	if len(opt.key.fullName) != 0 {
+		names = append(names, markdownCode("--"+opt.key.fullName))
+	}
+
+	return strings.Join(names, ", ")
+}
+
+//markdownCode returns text as inline code for table cell, empty text is kept empty
+func markdownCode(text string) string {
+	if len(text) == 0 {
+		return ""
+	}
+
+	return "`" + strings.Replace(text, "|", "\\|", -1) + "`"
+}
+
+//markdownEscape escapes text for table cell
+func markdownEscape(text string) string {
+	text = strings.Replace(text, "|", "\\|", -1)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+//paragraphs splits text to paragraphs by empty lines
+func paragraphs(text string) []string {
+	result := make([]string, 0)
+
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); len(paragraph) != 0 {
+			result = append(result, paragraph)
+		}
+	}
+
+	return result
+}
+
+//firstLine returns first line of text
+func firstLine(text string) string {
+	text = strings.TrimSpace(text)
+
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+
+	return strings.TrimSpace(text)
+}
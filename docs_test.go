@@ -0,0 +1,133 @@
+package comfyconf
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func prepareDocsConf() *Conf {
+	env := NewEnv()
+	env.SetUpperCaseNames(true)
+
+	conf := New(NewJSON("config.json"), env, NewFlags())
+	conf.SetProgram("server", "[options] <command>")
+	conf.SetVersion("1.2.0")
+	conf.SetDescription("Runs HTTP server.\n\nServer reads configuration from file, environment and flags.")
+
+	conf.String("", "db.host", "localhost", "Database host")
+	conf.Int("p", "db.port", 5432, "Database port")
+	conf.Exist("v", "verbose", "Verbose output")
+
+	conf.SetGroup("Database", "db.host", "db.port")
+
+	conf.AddCommand("serve", "Start server")
+	conf.AddCommand("migrate", "Run database migrations")
+	conf.AddExample("server --db.port=5433 serve", "Start server with custom port")
+
+	return conf
+}
+
+func TestConf_WriteMan(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, prepareDocsConf().WriteMan(&buffer))
+
+	assert.Equal(t, `.TH "SERVER" 1 "" "server 1.2.0"
+.SH NAME
+server \- Runs HTTP server.
+.SH SYNOPSIS
+.B server
+[options] <command>
+.SH DESCRIPTION
+Runs HTTP server.
+.PP
+Server reads configuration from file, environment and flags.
+.SH OPTIONS
+.TP
+\fB\-v, \-\-verbose\fR
+Verbose output
+.br
+env: ENV_VERBOSE, json: verbose
+.TP
+\fB\-h, \-\-help\fR
+Show help and exit
+.TP
+\fB\-\-version\fR
+Show version and exit
+.SS Database
+.TP
+\fB\-\-db.host=<string>\fR
+Database host
+.br
+Default: "localhost"
+.br
+env: ENV_DB_HOST, json: db.host
+.TP
+\fB\-p, \-\-db.port=<int>\fR
+Database port
+.br
+Default: 5432
+.br
+env: ENV_DB_PORT, json: db.port
+.SH COMMANDS
+.TP
+\fBserve\fR
+Start server
+.TP
+\fBmigrate\fR
+Run database migrations
+.SH EXAMPLES
+.PP
+Start server with custom port
+.PP
+.RS 4
+.nf
+server \-\-db.port=5433 serve
+.fi
+.RE
+`, buffer.String())
+}
+
+func TestConf_WriteMarkdown(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, prepareDocsConf().WriteMarkdown(&buffer))
+
+	assert.Equal(t, "# server\n"+
+		"\nVersion: `1.2.0`\n"+
+		"\nRuns HTTP server.\n\nServer reads configuration from file, environment and flags.\n"+
+		"\n## Synopsis\n\n```\nserver [options] <command>\n```\n"+
+		"\n## Options\n"+
+		"\n| Option | Type | Default | Environment | JSON | Description |\n|---|---|---|---|---|---|\n"+
+		"| `-v`, `--verbose` | existence |  | `ENV_VERBOSE` | `verbose` | Verbose output |\n"+
+		"| `-h`, `--help` | existence |  |  |  | Show help and exit |\n"+
+		"| `--version` | existence |  |  |  | Show version and exit |\n"+
+		"\n### Database\n"+
+		"\n| Option | Type | Default | Environment | JSON | Description |\n|---|---|---|---|---|---|\n"+
+		"| `--db.host` | string | `\"localhost\"` | `ENV_DB_HOST` | `db.host` | Database host |\n"+
+		"| `-p`, `--db.port` | int | `5432` | `ENV_DB_PORT` | `db.port` | Database port |\n"+
+		"\n## Commands\n\n| Command | Description |\n|---|---|\n"+
+		"| `serve` | Start server |\n"+
+		"| `migrate` | Run database migrations |\n"+
+		"\n## Examples\n"+
+		"\nStart server with custom port\n\n```\nserver --db.port=5433 serve\n```\n", buffer.String())
+}
+
+func TestConf_WriteDocs_Builtins(t *testing.T) {
+	conf := prepareDocsConf()
+
+	var buffer bytes.Buffer
+	assert.NoError(t, conf.WriteMan(&buffer))
+	assert.NoError(t, conf.WriteMarkdown(&buffer))
+	assert.NoError(t, conf.WriteCompletion(&buffer, Bash))
+
+	assert.Contains(t, buffer.String(), "--help")
+	assert.Contains(t, buffer.String(), "--version")
+	assert.Len(t, conf.options, 3)
+}
+
+func TestRoffEscape(t *testing.T) {
+	assert.Equal(t, "\\&.hidden \\e \\-\\-flag\n\\&'quoted", roffEscape(".hidden \\ --flag\n'quoted"))
+	assert.Equal(t, "\"say \\(dqhi\\(dq\"", roffQuote("say \"hi\""))
+	assert.Equal(t, "a \\| b", markdownEscape("a |\n b"))
+}
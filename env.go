@@ -1,8 +1,11 @@
 package comfyconf
 
 import (
+	"math"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -25,16 +28,29 @@ func NewEnvWithPrefix(prefix string) *Env {
 //Env structure that implements middleware interface for environment variables
 type Env struct {
 	Flags
-	prefix string
+	prefix           string
+	upperCaseNames   bool
+	upperCaseOptions map[string]bool
 }
 
-//Init initializing middleware for environment variables
+//Name returns name of environment middleware source
+func (f *Env) Name() string {
+	return "env"
+}
+
+//Init initializing middleware for environment variables.
+//Slice elements are ordered by their index, as order of environment variables is not defined
 func (f *Env) Init() error {
 
-	arrExpr := regexp.MustCompile(`^(.+)(\[[\d+]?])$`)
+	f.parsed = make(map[string]string)
+	f.parsedSlice = make(map[string][]interface{})
+
+	arrExpr := regexp.MustCompile(`^(.+)\[(\d*)]$`)
+
+	indexed := make(map[string][]envSliceItem)
 
 	for _, envPair := range os.Environ() {
-		pair := strings.Split(envPair, "=")
+		pair := strings.SplitN(envPair, "=", 2)
 
 		k := pair[0]
 		v := pair[1]
@@ -49,13 +65,12 @@ func (f *Env) Init() error {
 				}
 				k = match[1]
 
-				vSlice, isExist := f.parsedSlice[k]
-
-				if isExist {
-					f.parsedSlice[k] = append(vSlice, v)
-				} else {
-					f.parsedSlice[k] = append(make([]interface{}, 0), v)
+				index, err := strconv.Atoi(match[2])
+				if err != nil {
+					index = math.MaxInt32
 				}
+
+				indexed[k] = append(indexed[k], envSliceItem{index, v})
 				continue
 			}
 
@@ -63,5 +78,95 @@ func (f *Env) Init() error {
 		}
 	}
 
+	for k, items := range indexed {
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].index < items[j].index
+		})
+
+		vSlice := make([]interface{}, 0, len(items))
+
+		for _, item := range items {
+			vSlice = append(vSlice, item.value)
+		}
+
+		f.parsedSlice[k] = vSlice
+	}
+
 	return nil
 }
+
+type envSliceItem struct {
+	index int
+	value string
+}
+
+//EnvName returns upper-case environment name of option, where dots and dashes are replaced by underscores.
+//For example `db.host` is `DB_HOST`
+func EnvName(name string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name))
+}
+
+//SetUpperCaseNames enables matching of options by upper-case environment names of their full names,
+//so `db.host` can be provided as `ENV_DB_HOST`. Profile selector is always matched by upper-case name
+func (f *Env) SetUpperCaseNames(isEnabled bool) {
+	f.upperCaseNames = isEnabled
+}
+
+//setUpperCaseName enables matching of single option by upper-case environment name of its full name
+func (f *Env) setUpperCaseName(fullName string) {
+	if f.upperCaseOptions == nil {
+		f.upperCaseOptions = make(map[string]bool)
+	}
+
+	f.upperCaseOptions[fullName] = true
+}
+
+//isUpperCaseName checks, that option can be matched by upper-case environment name of its full name
+func (f *Env) isUpperCaseName(fullName string) bool {
+	return len(fullName) != 0 && (f.upperCaseNames || f.upperCaseOptions[fullName])
+}
+
+//ParseInt tries to get int from environment variables by option names or by upper-case environment name
+func (f *Env) ParseInt(shortName string, fullName string) (int, bool) {
+	if v, isOk := f.Flags.ParseInt(shortName, fullName); isOk || !f.isUpperCaseName(fullName) {
+		return v, isOk
+	}
+
+	return f.Flags.ParseInt("", EnvName(fullName))
+}
+
+//ParseString tries to get string from environment variables by option names or by upper-case environment name
+func (f *Env) ParseString(shortName string, fullName string) (string, bool) {
+	if v, isOk := f.Flags.ParseString(shortName, fullName); isOk || !f.isUpperCaseName(fullName) {
+		return v, isOk
+	}
+
+	return f.Flags.ParseString("", EnvName(fullName))
+}
+
+//ParseBool tries to get bool from environment variables by option names or by upper-case environment name
+func (f *Env) ParseBool(shortName string, fullName string) (bool, bool) {
+	if v, isOk := f.Flags.ParseBool(shortName, fullName); isOk || !f.isUpperCaseName(fullName) {
+		return v, isOk
+	}
+
+	return f.Flags.ParseBool("", EnvName(fullName))
+}
+
+//ParseExistence tries to check that environment variable exists by option names or by upper-case environment name
+func (f *Env) ParseExistence(shortName string, fullName string) (bool, bool) {
+	if v, isOk := f.Flags.ParseExistence(shortName, fullName); v || !f.isUpperCaseName(fullName) {
+		return v, isOk
+	}
+
+	return f.Flags.ParseExistence("", EnvName(fullName))
+}
+
+//ParseSlice tries to get slice from environment variables by option names or by upper-case environment name
+func (f *Env) ParseSlice(shortName string, fullName string) ([]interface{}, bool) {
+	if v, isOk := f.Flags.ParseSlice(shortName, fullName); isOk || !f.isUpperCaseName(fullName) {
+		return v, isOk
+	}
+
+	return f.Flags.ParseSlice("", EnvName(fullName))
+}
@@ -39,3 +39,65 @@ func TestEnv_Init(t *testing.T) {
 
 	assert.NoError(t, env.Init())
 }
+
+func TestEnv_UpperCaseNames(t *testing.T) {
+	_ = os.Setenv("UPPER_DB_HOST", "db.local")
+	defer os.Unsetenv("UPPER_DB_HOST")
+
+	_ = os.Setenv("UPPER_H", "short.local")
+	defer os.Unsetenv("UPPER_H")
+
+	env := NewEnvWithPrefix("UPPER_")
+	assert.NoError(t, env.Init())
+
+	_, isOk := env.ParseString("h", "db.host")
+	assert.False(t, isOk)
+
+	env.SetUpperCaseNames(true)
+
+	host, isOk := env.ParseString("h", "db.host")
+	assert.True(t, isOk)
+	assert.Equal(t, "db.local", host)
+	assert.Equal(t, "DB_HOST", EnvName("db-host"))
+
+	_, isOk = env.ParseString("h", "")
+	assert.False(t, isOk)
+}
+
+func TestEnv_UpperCaseNames_Profile(t *testing.T) {
+	_ = os.Setenv("UPPER_PROFILE", "prod")
+	_ = os.Setenv("UPPER_DB_HOST", "db.local")
+	defer os.Unsetenv("UPPER_PROFILE")
+	defer os.Unsetenv("UPPER_DB_HOST")
+
+	conf := New(NewEnvWithPrefix("UPPER_"))
+	profile := conf.Profile("", "profile", "dev", "Profile")
+	host := conf.String("", "db.host", "localhost", "Database host")
+
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, "prod", *profile)
+	assert.Equal(t, "localhost", *host)
+
+	env, _ := conf.optionEnvName(conf.Lookup("profile"))
+	assert.Equal(t, "UPPER_PROFILE", env)
+
+	env, _ = conf.optionEnvName(conf.Lookup("db.host"))
+	assert.Equal(t, "UPPER_db.host", env)
+}
+
+func TestEnv_Init_Reload(t *testing.T) {
+	env := NewEnvWithPrefix("RELOAD_")
+
+	_ = os.Setenv("RELOAD_tags[0]", "a")
+	assert.NoError(t, env.Init())
+
+	v, isOk := env.ParseSlice("t", "tags")
+	assert.True(t, isOk)
+	assert.Equal(t, []interface{}{"a"}, v)
+
+	_ = os.Unsetenv("RELOAD_tags[0]")
+	assert.NoError(t, env.Init())
+
+	_, isOk = env.ParseSlice("t", "tags")
+	assert.False(t, isOk)
+}
@@ -0,0 +1,210 @@
+package comfyconf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//NewEtcdClient returns KVClient for etcd v3 JSON gateway with address, like `http://127.0.0.1:2379`
+func NewEtcdClient(address string) *EtcdClient {
+	return &EtcdClient{
+		address: strings.TrimRight(address, "/"),
+		client:  http.DefaultClient,
+	}
+}
+
+//EtcdClient KVClient, that uses etcd v3 JSON gateway. Keys with prefix are read with range request
+//and changes are watched with watch stream
+type EtcdClient struct {
+	address string
+	token   string
+	client  *http.Client
+}
+
+//etcdInt int64 of gateway response, that is encoded as string
+type etcdInt uint64
+
+func (i *etcdInt) UnmarshalJSON(data []byte) error {
+	n, err := strconv.ParseUint(strings.Trim(string(data), `"`), 10, 64)
+
+	if err != nil {
+		return err
+	}
+
+	*i = etcdInt(n)
+
+	return nil
+}
+
+type etcdHeader struct {
+	Revision etcdInt `json:"revision"`
+}
+
+type etcdKeyValue struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type etcdRangeResponse struct {
+	Header etcdHeader     `json:"header"`
+	Kvs    []etcdKeyValue `json:"kvs"`
+}
+
+type etcdWatchResponse struct {
+	Result struct {
+		Header          etcdHeader        `json:"header"`
+		Created         bool              `json:"created"`
+		Canceled        bool              `json:"canceled"`
+		CancelReason    string            `json:"cancel_reason"`
+		CompactRevision etcdInt           `json:"compact_revision"`
+		Events          []json.RawMessage `json:"events"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+//SetToken sets authentication token, that is sent in `Authorization` header
+func (c *EtcdClient) SetToken(token string) {
+	c.token = token
+}
+
+//SetHTTPClient sets HTTP client, that is used for requests. http.DefaultClient is used by default
+func (c *EtcdClient) SetHTTPClient(client *http.Client) {
+	c.client = client
+}
+
+//List returns all pairs with keys starting with prefix and revision of store
+func (c *EtcdClient) List(prefix string) ([]KVPair, uint64, error) {
+	response, err := c.post(context.Background(), "/v3/kv/range", map[string]interface{}{
+		"key":       []byte(prefix),
+		"range_end": etcdRangeEnd(prefix),
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer response.Body.Close()
+
+	var rangeResponse etcdRangeResponse
+
+	if err := json.NewDecoder(response.Body).Decode(&rangeResponse); err != nil {
+		return nil, 0, fmt.Errorf("etcd: %v", err)
+	}
+
+	pairs := make([]KVPair, 0, len(rangeResponse.Kvs))
+
+	for _, kv := range rangeResponse.Kvs {
+		pairs = append(pairs, KVPair{string(kv.Key), kv.Value})
+	}
+
+	return pairs, uint64(rangeResponse.Header.Revision), nil
+}
+
+//Wait opens watch stream from next revision and returns revision of first change. When wait time is elapsed
+//without changes, provided revision is returned
+func (c *EtcdClient) Wait(ctx context.Context, prefix string, index uint64, waitTime time.Duration) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, waitTime)
+	defer cancel()
+
+	response, err := c.post(ctx, "/v3/watch", map[string]interface{}{
+		"create_request": map[string]interface{}{
+			"key":            []byte(prefix),
+			"range_end":      etcdRangeEnd(prefix),
+			"start_revision": strconv.FormatUint(index+1, 10),
+		},
+	})
+
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return index, nil
+		}
+
+		return 0, err
+	}
+
+	defer response.Body.Close()
+
+	decoder := json.NewDecoder(response.Body)
+
+	for {
+		var watchResponse etcdWatchResponse
+
+		if err := decoder.Decode(&watchResponse); err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return index, nil
+			}
+
+			return 0, fmt.Errorf("etcd: %v", err)
+		}
+
+		result := watchResponse.Result
+
+		switch {
+		case watchResponse.Error != nil:
+			return 0, fmt.Errorf("etcd: %s", watchResponse.Error.Message)
+		case result.Canceled && result.CompactRevision != 0:
+			//revisions were compacted, so changes can not be tracked and configuration is read again
+			return uint64(result.CompactRevision), nil
+		case result.Canceled:
+			return 0, fmt.Errorf("etcd: watch canceled: %s", result.CancelReason)
+		case len(result.Events) != 0:
+			return uint64(result.Header.Revision), nil
+		}
+	}
+}
+
+func (c *EtcdClient) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	content, err := json.Marshal(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, c.address+path, bytes.NewReader(content))
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	if len(c.token) != 0 {
+		request.Header.Set("Authorization", c.token)
+	}
+
+	response, err := c.client.Do(request.WithContext(ctx))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("etcd: unexpected status %s", response.Status)
+	}
+
+	return response, nil
+}
+
+//etcdRangeEnd returns end of range of keys with prefix, that is prefix with incremented last byte
+func etcdRangeEnd(prefix string) []byte {
+	end := []byte(prefix)
+
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	//prefix is empty or consists of 0xff bytes, so range covers all keys
+	return []byte{0}
+}
@@ -0,0 +1,87 @@
+package comfyconf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEtcdClient(t *testing.T) {
+	changes := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get("Authorization"))
+
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		switch r.URL.Path {
+		case "/v3/kv/range":
+			assert.Equal(t, "YXBwLw==", body["key"])
+			assert.Equal(t, "YXBwMA==", body["range_end"])
+
+			w.Write([]byte(`{"header": {"revision": "12"}, "kvs": [{"key": "YXBwL2RiL3BvcnQ=", "value": "NTQzMg==", "mod_revision": "10"}]}`))
+		case "/v3/watch":
+			create := body["create_request"].(map[string]interface{})
+			assert.Equal(t, "13", create["start_revision"])
+
+			w.Write([]byte(`{"result": {"header": {"revision": "12"}, "created": true}}` + "\n"))
+			w.(http.Flusher).Flush()
+
+			select {
+			case <-changes:
+				w.Write([]byte(`{"result": {"header": {"revision": "14"}, "events": [{"kv": {"key": "YXBwL2RiL3BvcnQ="}}]}}` + "\n"))
+			case <-r.Context().Done():
+			}
+		}
+	}))
+	defer server.Close()
+
+	c := NewEtcdClient(server.URL)
+	c.SetToken("token")
+
+	k := NewKV(c, "app/")
+	k.SetWaitTime(50 * time.Millisecond)
+	assert.NoError(t, k.Init())
+
+	port, isOk := k.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 5432, port)
+
+	changed, err := k.Changed()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(changes)
+	}()
+
+	k.SetWaitTime(5 * time.Second)
+
+	changed, err = k.Changed()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+}
+
+func TestEtcdClient_Canceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result": {"header": {"revision": "20"}, "canceled": true, "compact_revision": "15"}}`))
+	}))
+	defer server.Close()
+
+	index, err := NewEtcdClient(server.URL).Wait(context.Background(), "app/", 3, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(15), index)
+}
+
+func TestEtcdRangeEnd(t *testing.T) {
+	assert.Equal(t, []byte("app0"), etcdRangeEnd("app/"))
+	assert.Equal(t, []byte{'b'}, etcdRangeEnd("a\xff"))
+	assert.Equal(t, []byte{0}, etcdRangeEnd(""))
+}
@@ -0,0 +1,364 @@
+package comfyconf
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+//ExportFormat format of exported configuration
+type ExportFormat int
+
+const (
+	//ExportJSON exports configuration as nested JSON, that can be read by JSON middleware
+	ExportJSON ExportFormat = iota + 1
+	//ExportYAML exports configuration as nested YAML
+	ExportYAML
+	//ExportEnv exports configuration as environment variables file, that can be read by Env middleware
+	ExportEnv
+	//ExportFlags exports configuration as program arguments, one per line, that can be parsed by Flags middleware.
+	//Assignment of first Flags middleware is used
+	ExportFlags
+)
+
+//ExportOptions options for configuration export
+type ExportOptions struct {
+	//Defaults exports default values instead of effective values
+	Defaults bool
+	//RevealSecrets exports real values of secret options instead of redacted placeholder
+	RevealSecrets bool
+}
+
+//Export writes effective values of all options to writer in selected format. Secret values are redacted
+func (c *Conf) Export(format ExportFormat, w io.Writer) error {
+	return c.ExportWithOptions(format, w, ExportOptions{})
+}
+
+//ExportDefaults writes default values of all options to writer in selected format.
+//Can be used for generating starter configuration. Secret values are redacted
+func (c *Conf) ExportDefaults(format ExportFormat, w io.Writer) error {
+	return c.ExportWithOptions(format, w, ExportOptions{Defaults: true})
+}
+
+type exportEntry struct {
+	name       string
+	value      interface{}
+	optionType OptionType
+}
+
+//ExportWithOptions writes values of all options to writer in selected format using provided export options
+func (c *Conf) ExportWithOptions(format ExportFormat, w io.Writer, options ExportOptions) error {
+	entries := make([]exportEntry, 0, len(c.options))
+
+	for optKey, opt := range c.options {
+		if opt.builtin {
+			continue
+		}
+
+		value := opt.GetValue()
+
+		if options.Defaults {
+			value = opt.GetDefaultValue()
+		}
+
+		if opt.secret && !options.RevealSecrets {
+			value = Redacted
+		}
+
+		entries = append(entries, exportEntry{optKey.fullName, value, opt.optionType})
+	}
+
+	sort.Slice(entries, func(i, k int) bool {
+		return entries[i].name < entries[k].name
+	})
+
+	buffer := bufio.NewWriter(w)
+
+	var err error
+
+	switch format {
+	case ExportJSON:
+		err = exportJSON(buffer, entries)
+	case ExportYAML:
+		err = exportYAML(buffer, entries)
+	case ExportEnv:
+		err = exportEnv(buffer, entries, c.envPrefix())
+	case ExportFlags:
+		err = exportFlags(buffer, entries, c.flagsAssignment())
+	default:
+		err = fmt.Errorf("comfyconf: unknown export format %d", format)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return buffer.Flush()
+}
+
+//envPrefix returns prefix of first Env middleware or default prefix
+func (c *Conf) envPrefix() string {
+	for _, m := range c.middleware {
+		if env, isOk := m.(*Env); isOk {
+			return env.prefix
+		}
+	}
+
+	return "ENV_"
+}
+
+//flagsAssignment returns assignment of first Flags middleware or default assignment
+func (c *Conf) flagsAssignment() string {
+	for _, m := range c.middleware {
+		if flags, isOk := m.(*Flags); isOk && flags.assignment != "" {
+			return flags.assignment
+		}
+	}
+
+	return "="
+}
+
+//exportTree builds nested map from dotted option names
+func exportTree(entries []exportEntry) (map[string]interface{}, error) {
+	tree := make(map[string]interface{})
+
+	for _, entry := range entries {
+		path := strings.Split(entry.name, ".")
+		node := tree
+
+		for i, part := range path[:len(path)-1] {
+			child, isExist := node[part]
+
+			if !isExist {
+				child = make(map[string]interface{})
+				node[part] = child
+			}
+
+			childMap, isOk := child.(map[string]interface{})
+
+			if !isOk {
+				return nil, fmt.Errorf("comfyconf: option %q conflicts with option %q", entry.name, strings.Join(path[:i+1], "."))
+			}
+
+			node = childMap
+		}
+
+		last := path[len(path)-1]
+
+		if _, isExist := node[last]; isExist {
+			return nil, fmt.Errorf("comfyconf: option %q conflicts with nested options", entry.name)
+		}
+
+		node[last] = exportValue(entry.value)
+	}
+
+	return tree, nil
+}
+
+func exportValue(value interface{}) interface{} {
+	if v, isOk := value.([]interface{}); isOk && v == nil {
+		return make([]interface{}, 0)
+	}
+
+	return value
+}
+
+func exportJSON(w io.Writer, entries []exportEntry) error {
+	tree, err := exportTree(entries)
+
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(tree, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", content)
+
+	return err
+}
+
+func exportYAML(w io.Writer, entries []exportEntry) error {
+	tree, err := exportTree(entries)
+
+	if err != nil {
+		return err
+	}
+
+	return writeYAMLMap(w, tree, 0)
+}
+
+func writeYAMLMap(w io.Writer, node map[string]interface{}, indent int) error {
+	keys := make([]string, 0, len(node))
+
+	for k := range node {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	prefix := strings.Repeat("  ", indent)
+
+	for _, k := range keys {
+		key := yamlKey(k)
+
+		switch v := node[k].(type) {
+		case map[string]interface{}:
+			if _, err := fmt.Fprintf(w, "%s%s:\n", prefix, key); err != nil {
+				return err
+			}
+
+			if err := writeYAMLMap(w, v, indent+1); err != nil {
+				return err
+			}
+		case []interface{}:
+			if len(v) == 0 {
+				if _, err := fmt.Fprintf(w, "%s%s: []\n", prefix, key); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "%s%s:\n", prefix, key); err != nil {
+				return err
+			}
+
+			for _, item := range v {
+				if _, err := fmt.Fprintf(w, "%s  - %s\n", prefix, yamlScalar(item)); err != nil {
+					return err
+				}
+			}
+		default:
+			if _, err := fmt.Fprintf(w, "%s%s: %s\n", prefix, key, yamlScalar(v)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+//yamlKey formats mapping key, keys that could be read as other type or contain special characters are quoted
+func yamlKey(key string) string {
+	switch strings.ToLower(key) {
+	case "", "true", "false", "yes", "no", "on", "off", "null", "y", "n", "~":
+		return yamlScalar(key)
+	}
+
+	for i, r := range key {
+		isLetter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		isTail := r == '-' || (r >= '0' && r <= '9')
+
+		if !isLetter && (i == 0 || !isTail) {
+			return yamlScalar(key)
+		}
+	}
+
+	return key
+}
+
+//yamlScalar formats scalar value, strings are always written in double quoted JSON compatible form
+func yamlScalar(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		content, _ := json.Marshal(v)
+		return string(content)
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func exportEnv(w io.Writer, entries []exportEntry, prefix string) error {
+	for _, entry := range entries {
+		if entry.optionType == existenceType {
+			if entry.value == true {
+				if _, err := fmt.Fprintf(w, "%s%s=true\n", prefix, entry.name); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err := writeArguments(w, prefix+entry.name, "=", entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exportFlags(w io.Writer, entries []exportEntry, assignment string) error {
+	for _, entry := range entries {
+		if strings.Contains(entry.name, assignment) {
+			return fmt.Errorf("comfyconf: option %q can not be exported, name contains assignment %q", entry.name, assignment)
+		}
+
+		if entry.optionType == existenceType {
+			if entry.value == true {
+				if _, err := fmt.Fprintf(w, "--%s\n", entry.name); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err := writeArguments(w, "--"+entry.name, assignment, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//writeArguments writes key=value lines, slices are written as indexed keys.
+//Values, that can not be read back from single line, are rejected
+func writeArguments(w io.Writer, key string, assignment string, entry exportEntry) error {
+	switch v := entry.value.(type) {
+	case []interface{}:
+		for i, item := range v {
+			value, err := argumentValue(entry.name, assignment, item)
+
+			if err != nil {
+				return err
+			}
+
+			if _, err := fmt.Fprintf(w, "%s[%d]%s%s\n", key, i, assignment, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		value, err := argumentValue(entry.name, assignment, v)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "%s%s%s\n", key, assignment, value)
+		return err
+	}
+}
+
+//argumentValue formats value of argument. Line breaks can not be represented in one argument per line form
+//and value starting with dash is read as separate flag, if assignment is blank
+func argumentValue(name string, assignment string, value interface{}) (string, error) {
+	v := fmt.Sprintf("%v", value)
+
+	if strings.ContainsAny(v, "\r\n\x00") {
+		return "", fmt.Errorf("comfyconf: value of option %q can not be exported, it contains line break or NUL character", name)
+	}
+
+	if strings.TrimSpace(assignment) == "" && strings.HasPrefix(v, "-") {
+		return "", fmt.Errorf("comfyconf: value of option %q can not be exported, it starts with dash", name)
+	}
+
+	return v, nil
+}
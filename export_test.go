@@ -0,0 +1,167 @@
+package comfyconf
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func prepareExportConf(middleware ...Middleware) *Conf {
+	conf := New(append(middleware, prepareFlags([]string{"--db.host=db.local", "--db.port=5433", "--tags[]=a", "--tags[]=b=c", "--verbose"}, "="))...)
+
+	conf.String("h", "db.host", "localhost", "Database host")
+	conf.Int("p", "db.port", 5432, "Database port")
+	conf.Bool("d", "debug", false, "Debug mode")
+	conf.Exist("v", "verbose", "Verbose output")
+	conf.Slice("t", "tags", nil, "Tags")
+
+	return conf
+}
+
+func TestConf_Export_JSON(t *testing.T) {
+	conf := prepareExportConf()
+	assert.NoError(t, conf.Parse())
+
+	var buffer bytes.Buffer
+	assert.NoError(t, conf.Export(ExportJSON, &buffer))
+
+	restored := New(NewJSONWithCustomReader(func(j *JSON) ([]byte, error) {
+		return buffer.Bytes(), nil
+	}))
+
+	host := restored.String("h", "db.host", "", "Database host")
+	port := restored.Int("p", "db.port", 0, "Database port")
+	tags := restored.Slice("t", "tags", nil, "Tags")
+
+	assert.NoError(t, restored.Parse())
+
+	assert.Equal(t, "db.local", *host)
+	assert.Equal(t, 5433, *port)
+	assert.Equal(t, []interface{}{"a", "b=c"}, *tags)
+}
+
+func TestConf_ExportDefaults_YAML(t *testing.T) {
+	conf := prepareExportConf()
+	assert.NoError(t, conf.Parse())
+
+	var buffer bytes.Buffer
+	assert.NoError(t, conf.ExportDefaults(ExportYAML, &buffer))
+
+	assert.Equal(t, `db:
+  host: "localhost"
+  port: 5432
+debug: false
+tags: []
+verbose: false
+`, buffer.String())
+}
+
+func TestConf_Export_Env(t *testing.T) {
+	conf := prepareExportConf(NewEnvWithPrefix("EXPORT_"))
+	assert.NoError(t, conf.Parse())
+
+	var buffer bytes.Buffer
+	assert.NoError(t, conf.Export(ExportEnv, &buffer))
+
+	assert.Contains(t, buffer.String(), "EXPORT_db.host=db.local\n")
+	assert.Contains(t, buffer.String(), "EXPORT_verbose=true\n")
+
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		kv := strings.SplitN(line, "=", 2)
+		assert.NoError(t, os.Setenv(kv[0], kv[1]))
+		defer os.Unsetenv(kv[0])
+	}
+
+	restored := New(NewEnvWithPrefix("EXPORT_"))
+
+	port := restored.Int("p", "db.port", 0, "Database port")
+	verbose := restored.Exist("v", "verbose", "Verbose output")
+	tags := restored.Slice("t", "tags", nil, "Tags")
+
+	assert.NoError(t, restored.Parse())
+
+	assert.Equal(t, 5433, *port)
+	assert.True(t, *verbose)
+	assert.Equal(t, []interface{}{"a", "b=c"}, *tags)
+}
+
+func TestConf_Export_Flags(t *testing.T) {
+	conf := prepareExportConf()
+	assert.NoError(t, conf.Parse())
+
+	var buffer bytes.Buffer
+	assert.NoError(t, conf.Export(ExportFlags, &buffer))
+
+	args := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	restored := prepareConf(args, "=")
+
+	host := restored.String("h", "db.host", "", "Database host")
+	debug := restored.Bool("d", "debug", true, "Debug mode")
+	verbose := restored.Exist("v", "verbose", "Verbose output")
+	tags := restored.Slice("t", "tags", nil, "Tags")
+
+	assert.NoError(t, restored.Parse())
+
+	assert.Equal(t, "db.local", *host)
+	assert.False(t, *debug)
+	assert.True(t, *verbose)
+	assert.Equal(t, []interface{}{"a", "b=c"}, *tags)
+}
+
+func TestConf_Export_Conflict(t *testing.T) {
+	conf := prepareConf([]string{}, "=")
+
+	conf.String("d", "db", "", "Database")
+	conf.String("h", "db.host", "", "Database host")
+
+	var buffer bytes.Buffer
+	assert.Error(t, conf.Export(ExportJSON, &buffer))
+	assert.Error(t, conf.Export(ExportFormat(0), &buffer))
+}
+
+func TestConf_Export_Flags_RoundTrip(t *testing.T) {
+	conf := New(prepareFlags([]string{"--db.host:-x=y:z", "--tags[]:-a", "--tags[]:b=c"}, ":"))
+
+	conf.String("h", "db.host", "", "Database host")
+	conf.Slice("t", "tags", nil, "Tags")
+
+	assert.NoError(t, conf.Parse())
+
+	var buffer bytes.Buffer
+	assert.NoError(t, conf.Export(ExportFlags, &buffer))
+	assert.Equal(t, "--db.host:-x=y:z\n--tags[0]:-a\n--tags[1]:b=c\n", buffer.String())
+
+	args := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	restored := prepareConf(args, ":")
+
+	host := restored.String("h", "db.host", "", "Database host")
+	tags := restored.Slice("t", "tags", nil, "Tags")
+
+	assert.NoError(t, restored.Parse())
+
+	assert.Equal(t, "-x=y:z", *host)
+	assert.Equal(t, []interface{}{"-a", "b=c"}, *tags)
+}
+
+func TestConf_Export_Unrepresentable(t *testing.T) {
+	conf := prepareConf([]string{"--db.host=a"}, "=")
+	conf.String("h", "db.host", "", "Database host")
+
+	assert.NoError(t, conf.Parse())
+	conf.Lookup("db.host").Put("a\nb")
+
+	var buffer bytes.Buffer
+	assert.Error(t, conf.Export(ExportFlags, &buffer))
+	assert.Error(t, conf.Export(ExportEnv, &buffer))
+	assert.NoError(t, conf.Export(ExportJSON, &buffer))
+
+	blank := prepareConf([]string{"--db.host -a"}, " ")
+	blank.String("h", "db.host", "", "Database host")
+
+	assert.NoError(t, blank.Parse())
+	assert.Equal(t, "-a", blank.Lookup("db.host").GetValue())
+	assert.Error(t, blank.Export(ExportFlags, &buffer))
+}
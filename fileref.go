@@ -0,0 +1,144 @@
+package comfyconf
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const (
+	//DefaultFileRefLimit default size limit of files, that are referenced by option values
+	DefaultFileRefLimit int64 = 1 << 20
+
+	fileRefPrefix = "@"
+	fileRefSuffix = "_FILE"
+)
+
+//SetFileRefs enables or disables file references for all options.
+//When enabled, value `@/path/to/file` or value of option with `_FILE` suffix is replaced by content of that file
+func (c *Conf) SetFileRefs(enabled bool) {
+	c.fileRefs = enabled
+}
+
+//SetFileRefLimit sets size limit in bytes of files, that are referenced by option values
+func (c *Conf) SetFileRefLimit(limit int64) {
+	c.fileRefLimit = limit
+}
+
+//SetFileRef enables or disables file references for option.
+//When enabled, value `@/path/to/file` or value of option with `_FILE` suffix is replaced by content of that file
+func (o *Option) SetFileRef(enabled bool) {
+	o.fileRef = enabled
+}
+
+func (c *Conf) isFileRefEnabled(opt *Option) bool {
+	if opt.optionType == sliceType || opt.optionType == existenceType {
+		return false
+	}
+
+	return c.fileRefs || opt.fileRef
+}
+
+//parseFileRef tries to get option value from file, that is referenced by `_FILE` suffixed option or by `@` prefixed value.
+//Value `@@text` is used as literal `@text`
+func (c *Conf) parseFileRef(m Middleware, optKey OptionKey, opt *Option) (interface{}, bool, error) {
+	shortName := optKey.shortName
+
+	if len(shortName) != 0 {
+		shortName += fileRefSuffix
+	}
+
+	path, isOk := lookupFileRef(m, shortName, optKey.fullName+fileRefSuffix)
+
+	if !isOk {
+		raw, isRaw := lookupFileRef(m, optKey.shortName, optKey.fullName)
+
+		if !isRaw || !strings.HasPrefix(raw, fileRefPrefix) {
+			return nil, false, nil
+		}
+
+		if strings.HasPrefix(raw, fileRefPrefix+fileRefPrefix) {
+			v, isOk := convertString(opt.optionType, strings.TrimPrefix(raw, fileRefPrefix))
+			return v, isOk, nil
+		}
+
+		path = strings.TrimPrefix(raw, fileRefPrefix)
+	}
+
+	content, err := readFileRef(path, c.fileRefLimit)
+
+	if err != nil {
+		return nil, false, fmt.Errorf("comfyconf: option %q from %s: %v", optKey.fullName, middlewareName(m), err)
+	}
+
+	v, isOk := convertString(opt.optionType, content)
+
+	if !isOk {
+		return nil, false, fmt.Errorf("comfyconf: option %q from %s: content of file %q is not valid %s value",
+			optKey.fullName, middlewareName(m), path, opt.optionType)
+	}
+
+	return v, true, nil
+}
+
+//fileRefLookup is implemented by middlewares, that report type errors from ParseString in strict mode.
+//Values are probed for file references without reporting, as option value can have other type
+type fileRefLookup interface {
+	lookupString(shortName string, fullName string) (string, bool)
+}
+
+//lookupFileRef returns string value, that can be file reference
+func lookupFileRef(m Middleware, shortName string, fullName string) (string, bool) {
+	if lookup, isOk := m.(fileRefLookup); isOk {
+		return lookup.lookupString(shortName, fullName)
+	}
+
+	return m.ParseString(shortName, fullName)
+}
+
+//readFileRef reads referenced file with size limit and strips trailing newlines
+func readFileRef(path string, limit int64) (string, error) {
+	if len(path) == 0 {
+		return "", fmt.Errorf("empty file reference")
+	}
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return "", fmt.Errorf("cannot read referenced file: %v", err)
+	}
+
+	defer file.Close()
+
+	return readRef(file, path, limit)
+}
+
+//readFSFileRef reads file of filesystem with size limit and strips trailing newlines
+func readFSFileRef(fsys fs.FS, path string, limit int64) (string, error) {
+	file, err := fsys.Open(path)
+
+	if err != nil {
+		return "", fmt.Errorf("cannot read referenced file: %v", err)
+	}
+
+	defer file.Close()
+
+	return readRef(file, path, limit)
+}
+
+func readRef(file io.Reader, path string, limit int64) (string, error) {
+	content, err := ioutil.ReadAll(io.LimitReader(file, limit+1))
+
+	if err != nil {
+		return "", fmt.Errorf("cannot read referenced file %q: %v", path, err)
+	}
+
+	if int64(len(content)) > limit {
+		return "", fmt.Errorf("referenced file %q exceeds size limit of %d bytes", path, limit)
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
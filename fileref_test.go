@@ -0,0 +1,120 @@
+package comfyconf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func prepareFileRef(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "comfyconf")
+	assert.NoError(t, err)
+
+	path := filepath.Join(dir, "secret")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+
+	return path
+}
+
+func TestConf_FileRef_Prefix(t *testing.T) {
+	path := prepareFileRef(t, "hunter2\n\n")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	conf := prepareConf([]string{"--db-password=@" + path, "--user=@@admin", "--port=@" + path}, "=")
+	conf.SetFileRefs(true)
+
+	password := conf.String("p", "db-password", "", "Password")
+	user := conf.String("u", "user", "", "User")
+
+	assert.NoError(t, conf.Parse())
+
+	assert.Equal(t, "hunter2", *password)
+	assert.Equal(t, "@admin", *user)
+
+	conf.Int("P", "port", 0, "Port")
+
+	err := conf.Parse()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `"port"`)
+	assert.NotContains(t, err.Error(), "hunter2")
+}
+
+func TestConf_FileRef_Suffix(t *testing.T) {
+	path := prepareFileRef(t, "5433\n")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	_ = os.Setenv("FILEREF_DB_PORT_FILE", path)
+	defer os.Unsetenv("FILEREF_DB_PORT_FILE")
+
+	conf := New(NewEnvWithPrefix("FILEREF_"))
+	port := conf.Int("", "DB_PORT", 5432, "Database port")
+
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, 5432, *port)
+
+	conf.Lookup("DB_PORT").SetFileRef(true)
+
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, 5433, *port)
+	assert.Equal(t, "env", conf.Lookup("DB_PORT").GetSource())
+}
+
+func TestConf_FileRef_JSON(t *testing.T) {
+	path := prepareFileRef(t, "token")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	conf := New(NewJSONWithCustomReader(func(j *JSON) ([]byte, error) {
+		return []byte(`{"api": {"token": "@` + path + `"}}`), nil
+	}))
+	conf.SetFileRefs(true)
+
+	token := conf.String("t", "api.token", "", "API token")
+
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, "token", *token)
+}
+
+func TestConf_FileRef_JSON_Strict(t *testing.T) {
+	j := NewJSONWithCustomReader(func(j *JSON) ([]byte, error) {
+		return []byte(`{"port": 5432, "host": 1}`), nil
+	})
+	j.SetStrict(true)
+
+	conf := New(j)
+	conf.SetFileRefs(true)
+
+	port := conf.Int("p", "port", 0, "Port")
+	conf.String("h", "host", "", "Host")
+
+	err := conf.Parse()
+	assert.IsType(t, StrictErrors{}, err)
+	assert.Len(t, err.(StrictErrors), 1)
+	assert.Contains(t, err.Error(), "host")
+	assert.Equal(t, 5432, *port)
+}
+
+func TestConf_FileRef_Errors(t *testing.T) {
+	path := prepareFileRef(t, strings.Repeat("x", 16))
+	defer os.RemoveAll(filepath.Dir(path))
+
+	conf := prepareConf([]string{"--key=@" + path}, "=")
+	conf.SetFileRefs(true)
+	conf.SetFileRefLimit(8)
+	conf.String("k", "key", "", "Key")
+
+	err := conf.Parse()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds size limit of 8 bytes")
+
+	conf = prepareConf([]string{"--key=@" + path + ".missing"}, "=")
+	conf.SetFileRefs(true)
+	conf.String("k", "key", "", "Key")
+
+	err = conf.Parse()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `option "key" from flags`)
+}
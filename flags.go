@@ -3,7 +3,6 @@ package comfyconf
 import (
 	"os"
 	"regexp"
-	"strconv"
 	"strings"
 )
 
@@ -14,9 +13,12 @@ func NewFlags() *Flags {
 
 //NewFlagsWithCustomAssignment creates new flags middleware with custom assignment
 func NewFlagsWithCustomAssignment(assignment string) *Flags {
-	return NewFlagsWithCustomParser(func(arg string) (string, string) {
+	f := NewFlagsWithCustomParser(func(arg string) (string, string) {
 		return DefaultFlagsParser(arg, assignment)
 	})
+	f.assignment = assignment
+
+	return f
 }
 
 //NewFlagsWithCustomParser creates new flags middleware with custom parser
@@ -26,6 +28,7 @@ func NewFlagsWithCustomParser(parser func(arg string) (string, string)) *Flags {
 		parser,
 		make(map[string]string),
 		make(map[string][]interface{}),
+		"",
 	}
 }
 
@@ -62,16 +65,23 @@ type Flags struct {
 
 	parsed      map[string]string
 	parsedSlice map[string][]interface{}
+
+	//assignment is used by flags export, it is empty for custom parser
+	assignment string
+}
+
+//Name returns name of flags middleware source
+func (f *Flags) Name() string {
+	return "flags"
 }
 
 //Init initializing middleware for program arguments
 func (f *Flags) Init() error {
 
-	if f.parsed == nil {
-		f.parsed = make(map[string]string)
-	}
+	f.parsed = make(map[string]string)
+	f.parsedSlice = make(map[string][]interface{})
 
-	arrExpr := regexp.MustCompile(`^(.+)(\[[\d+]?])$`)
+	arrExpr := regexp.MustCompile(`^(.+)(\[\d*])$`)
 
 	for _, arg := range f.args {
 		k, v := f.parser(arg)
@@ -130,12 +140,12 @@ func (f *Flags) ParseInt(shortName string, fullName string) (int, bool) {
 		return 0, false
 	}
 
-	vi, err := strconv.Atoi(v)
-	if err != nil {
+	vi, isOk := convertString(intType, v)
+	if !isOk {
 		return 0, false
 	}
 
-	return vi, true
+	return vi.(int), true
 }
 
 //ParseString tries to get string from flags middleware
@@ -151,11 +161,11 @@ func (f *Flags) ParseBool(shortName string, fullName string) (bool, bool) {
 		return false, false
 	}
 
-	v1, err := strconv.ParseBool(v)
-	if err != nil {
+	v1, isOk := convertString(boolType, v)
+	if !isOk {
 		return false, false
 	}
-	return v1, true
+	return v1.(bool), true
 }
 
 //ParseExistence tries to check that flag exists
@@ -178,5 +178,6 @@ func prepareFlags(args []string, assignment string) *Flags {
 		},
 		make(map[string]string),
 		make(map[string][]interface{}),
+		assignment,
 	}
 }
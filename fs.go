@@ -0,0 +1,161 @@
+package comfyconf
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+//NewJSONFS returns pointer to instance of JSON configuration middleware, that reads file from filesystem,
+//like embed.FS or fstest.MapFS. Included and profile files are read from same filesystem
+func NewJSONFS(fsys fs.FS, file string) *JSON {
+	return &JSON{
+		path:   file,
+		reader: DefaultJSONReader,
+		fsys:   fsys,
+	}
+}
+
+//FSFile returns layer of filesystem, that must exist. Used for defaults, that are shipped inside of binary
+func FSFile(fsys fs.FS, pattern string) FileLayer {
+	return FileLayer{Pattern: pattern, FS: fsys}
+}
+
+//OptionalFSFile returns layer of filesystem, that is skipped when file does not exist
+func OptionalFSFile(fsys fs.FS, pattern string) FileLayer {
+	return FileLayer{Pattern: pattern, Optional: true, FS: fsys}
+}
+
+//NewDirectoryFS creates directory middleware, that reads directory of filesystem. Use `.` for root directory
+func NewDirectoryFS(fsys fs.FS, dir string) *Directory {
+	d := NewDirectory(dir)
+	d.fsys = fsys
+
+	return d
+}
+
+//fsPath converts file path to slash separated path of fs.FS, that has no leading slash. Root is `.`
+func fsPath(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+
+	if len(name) == 0 {
+		return "."
+	}
+
+	return name
+}
+
+//fileID identifies file, that is read by middleware. Files of filesystems have index of filesystem in filesystems
+//of middleware starting from one, operating system files have zero index, so embedded file and file on disk with
+//same path are told apart
+type fileID struct {
+	path string
+	fs   int
+}
+
+//fsFile returns identifier of file of filesystem with index in filesystems of middleware
+func fsFile(path string, index int) fileID {
+	return fileID{path: path, fs: index + 1}
+}
+
+//filePaths returns paths of file identifiers joined by separator
+func filePaths(files []fileID, separator string) string {
+	paths := make([]string, len(files))
+
+	for i, file := range files {
+		paths[i] = file.path
+	}
+
+	return strings.Join(paths, separator)
+}
+
+//related returns identifier of path in same filesystem as file, like included or profile file
+func (file fileID) related(path string) fileID {
+	return fileID{path: path, fs: file.fs}
+}
+
+//rootFile returns identifier of middleware file
+func (j *JSON) rootFile() fileID {
+	if j.fsys != nil {
+		return fsFile(j.path, 0)
+	}
+
+	return fileID{path: j.path}
+}
+
+//fileSystem returns filesystem of file or nil for operating system files
+func (j *JSON) fileSystem(file fileID) fs.FS {
+	if file.fs == 0 || file.fs > len(j.filesystems) {
+		return nil
+	}
+
+	return j.filesystems[file.fs-1]
+}
+
+//readFile reads file with middleware reader. Includes and profile files of filesystems are read from same filesystem
+func (j *JSON) readFile(file fileID) ([]byte, error) {
+	return j.reader(&JSON{path: file.path, reader: j.reader, fsys: j.fileSystem(file)})
+}
+
+//checkWritable returns error, when file was read from filesystem, that can not be written
+func (j *JSON) checkWritable(file fileID) error {
+	if file.fs != 0 {
+		return fmt.Errorf("comfyconf: %s: file of fs.FS can not be saved", file.path)
+	}
+
+	return nil
+}
+
+//walkFS returns files of directory of filesystem by their dotted keys
+func (d *Directory) walkFS() (map[string]string, error) {
+	files := make(map[string]string)
+	root := fsPath(d.path)
+
+	err := fs.WalkDir(d.fsys, root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if name == root {
+			return nil
+		}
+
+		if strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := fs.Stat(d.fsys, name)
+
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			key := strings.TrimPrefix(name, root+"/")
+
+			if root == "." {
+				key = name
+			}
+
+			files[strings.Replace(key, "/", ".", -1)] = name
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("comfyconf: directory %q: %v", d.path, err)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,178 @@
+package comfyconf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJSONFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/app.json":      {Data: []byte(`{"$include": "base.json", "name": "app"}`)},
+		"config/base.json":     {Data: []byte(`{"db": {"host": "localhost", "port": 5432}}`)},
+		"config/app.prod.json": {Data: []byte(`{"db": {"host": "prod"}}`)},
+	}
+
+	j := NewJSONFS(fsys, "config/app.json")
+	assert.NoError(t, j.Init())
+
+	host, isOk := j.ParseString("host", "db.host")
+	assert.True(t, isOk)
+	assert.Equal(t, "localhost", host)
+
+	assert.NoError(t, j.SetProfile("prod"))
+
+	host, isOk = j.ParseString("host", "db.host")
+	assert.True(t, isOk)
+	assert.Equal(t, "prod", host)
+
+	assert.EqualError(t, j.Save(map[string]interface{}{"name": "other"}), "comfyconf: config/app.json: file of fs.FS can not be saved")
+
+	assert.Error(t, NewJSONFS(fsys, "missing.json").Init())
+}
+
+func TestJSONFiles_FS(t *testing.T) {
+	embedded := fstest.MapFS{
+		"defaults.json":      {Data: []byte(`{"db": {"host": "localhost", "port": 5432}, "name": "app"}`)},
+		"conf.d/cache.json":  {Data: []byte(`{"cache": {"size": 10}}`)},
+		"conf.d/.hidden.txt": {Data: []byte(`x`)},
+	}
+
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json": `{"db": {"port": 6543}}`,
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+
+	files := NewJSONFiles(
+		FSFile(embedded, "defaults.json"),
+		OptionalFSFile(embedded, "conf.d/*.json"),
+		OptionalFSFile(embedded, "missing.json"),
+		OptionalFile(path),
+	)
+	assert.NoError(t, files.Init())
+	assert.Equal(t, []string{"defaults.json", "conf.d/cache.json", path}, files.Files())
+
+	port, isOk := files.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 6543, port)
+
+	size, isOk := files.ParseInt("size", "cache.size")
+	assert.True(t, isOk)
+	assert.Equal(t, 10, size)
+
+	assert.NoError(t, files.Save(map[string]interface{}{"db.port": 7654}))
+	assert.EqualError(t, files.Save(map[string]interface{}{"name": "other"}), "comfyconf: defaults.json: file of fs.FS can not be saved")
+
+	assert.Error(t, NewJSONFiles(FSFile(embedded, "missing.json")).Init())
+}
+
+func TestNewDirectoryFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"secrets/db/password": {Data: []byte("secret\n")},
+		"secrets/hosts":       {Data: []byte("a\nb\n")},
+		"secrets/.hidden/key": {Data: []byte("x")},
+	}
+
+	d := NewDirectoryFS(fsys, "secrets")
+	assert.NoError(t, d.Init())
+
+	password, isOk := d.ParseString("password", "db.password")
+	assert.True(t, isOk)
+	assert.Equal(t, "secret", password)
+
+	hosts, isOk := d.ParseSlice("h", "hosts")
+	assert.True(t, isOk)
+	assert.Equal(t, []interface{}{"a", "b"}, hosts)
+
+	_, isOk = d.ParseString("key", ".hidden.key")
+	assert.False(t, isOk)
+
+	changed, err := d.Changed()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	fsys["secrets/hosts"] = &fstest.MapFile{Data: []byte("c\n")}
+
+	changed, err = d.Changed()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	root := NewDirectoryFS(fsys, ".")
+	assert.NoError(t, root.Init())
+
+	password, isOk = root.ParseString("password", "secrets.db.password")
+	assert.True(t, isOk)
+	assert.Equal(t, "secret", password)
+}
+
+func TestFSPath(t *testing.T) {
+	assert.Equal(t, "config/app.json", fsPath("./config/app.json"))
+	assert.Equal(t, "config/app.json", fsPath("/config/../config/app.json"))
+	assert.Equal(t, ".", fsPath("."))
+	assert.Equal(t, ".", fsPath(""))
+}
+
+func TestJSONFiles_FS_SamePath(t *testing.T) {
+	embedded := fstest.MapFS{
+		"config.json":      {Data: []byte(`{"$include": "base.json", "name": "embedded", "db": {"host": "localhost"}}`)},
+		"base.json":        {Data: []byte(`{"timeout": 10}`)},
+		"config.prod.json": {Data: []byte(`{"db": {"host": "prod"}}`)},
+	}
+
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json":      `{"name": "disk"}`,
+		"config.prod.json": `{"level": "warn"}`,
+	})
+	defer os.RemoveAll(dir)
+
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	files := NewJSONFiles(FSFile(embedded, "config.json"), OptionalFile("config.json"))
+	assert.NoError(t, files.Init())
+	assert.Equal(t, []string{"config.json", "config.json"}, files.Files())
+	assert.NoError(t, files.SetProfile("prod"))
+
+	host, isOk := files.ParseString("host", "db.host")
+	assert.True(t, isOk)
+	assert.Equal(t, "prod", host)
+
+	level, isOk := files.ParseString("level", "level")
+	assert.True(t, isOk)
+	assert.Equal(t, "warn", level)
+
+	timeout, isOk := files.ParseInt("timeout", "timeout")
+	assert.True(t, isOk)
+	assert.Equal(t, 10, timeout)
+
+	origin, isOk := files.Origin("timeout", "timeout")
+	assert.True(t, isOk)
+	assert.Equal(t, "json:base.json", origin)
+
+	assert.NoError(t, files.Save(map[string]interface{}{"name": "saved"}))
+	assert.EqualError(t, files.Save(map[string]interface{}{"timeout": 5}), "comfyconf: base.json: file of fs.FS can not be saved")
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name": "saved"}`, string(content))
+}
+
+func TestFileID(t *testing.T) {
+	embedded := fstest.MapFS{"config.json": {Data: []byte(`{}`)}}
+
+	disk := NewJSON("config.json").rootFile()
+	fsys := NewJSONFS(embedded, "config.json").rootFile()
+
+	assert.NotEqual(t, disk, fsys)
+	assert.Equal(t, fileID{path: "config.json"}, disk)
+	assert.Equal(t, fsFile("base.json", 0), fsys.related("base.json"))
+	assert.Equal(t, "config.json -> base.json", filePaths([]fileID{fsys, fsys.related("base.json")}, " -> "))
+}
@@ -0,0 +1,17 @@
+module github.com/drewoko/comfyconf
+
+go 1.17
+
+require (
+	filippo.io/age v1.0.0
+	github.com/BurntSushi/toml v1.3.2
+	github.com/stretchr/testify v1.8.2
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/crypto v0.10.0 // indirect
+	golang.org/x/sys v0.9.0 // indirect
+)
@@ -0,0 +1,281 @@
+package comfyconf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultUsageWidth = 80
+	maxFlagColumn     = 32
+)
+
+//SetProgram sets program name and synopsis, that are shown in usage.
+//By default program name is taken from os.Args and synopsis is `[options]`
+func (c *Conf) SetProgram(name string, synopsis string) {
+	c.program = name
+	c.synopsis = synopsis
+}
+
+//SetGroup sets group of options with provided full or short names. Groups are shown as separate sections in usage
+func (c *Conf) SetGroup(group string, names ...string) {
+	for _, name := range names {
+		if opt := c.Lookup(name); opt != nil {
+			opt.SetGroup(group)
+		}
+	}
+}
+
+//WriteUsage writes usage information to writer. Options are shown in declaration order and grouped by sections,
+//every option has type placeholder, default value, environment name and JSON path. Text is wrapped
+//to terminal width taken from $COLUMNS
+func (c *Conf) WriteUsage(w io.Writer) error {
+	buffer := bufio.NewWriter(w)
+
+	fmt.Fprintf(buffer, "Usage: %s %s\n", c.programName(), c.programSynopsis())
+
+	groups, grouped := groupOptions(c.sortedOptions())
+
+	flagColumn := 0
+
+	for _, opt := range c.sortedOptions() {
+		if l := len(opt.usageFlag()) + 4; l > flagColumn {
+			flagColumn = l
+		}
+	}
+
+	if flagColumn > maxFlagColumn {
+		flagColumn = maxFlagColumn
+	}
+
+	width := usageWidth()
+
+	for _, group := range groups {
+		title := group
+
+		if len(title) == 0 {
+			title = "Options"
+		}
+
+		fmt.Fprintf(buffer, "\n%s:\n", title)
+
+		for _, opt := range grouped[group] {
+			c.writeOptionUsage(buffer, opt, flagColumn, width)
+		}
+	}
+
+	return buffer.Flush()
+}
+
+func (c *Conf) writeOptionUsage(w io.Writer, opt *Option, flagColumn int, width int) {
+	flag := "  " + opt.usageFlag()
+	lines := make([]string, 0)
+
+	description := opt.description
+
+	if def := opt.usageDefault(); len(def) != 0 {
+		description = strings.TrimSpace(description + " (default: " + def + ")")
+	}
+
+	lines = append(lines, wrapText(description, width-flagColumn)...)
+
+	if sources := c.usageSources(opt); len(sources) != 0 {
+		lines = append(lines, wrapText(sources, width-flagColumn)...)
+	}
+
+	padding := strings.Repeat(" ", flagColumn)
+
+	if len(flag)+2 > flagColumn {
+		fmt.Fprintln(w, flag)
+	} else if len(lines) != 0 {
+		fmt.Fprint(w, flag+strings.Repeat(" ", flagColumn-len(flag)))
+		fmt.Fprintln(w, lines[0])
+		lines = lines[1:]
+	} else {
+		fmt.Fprintln(w, flag)
+	}
+
+	for _, line := range lines {
+		fmt.Fprintln(w, padding+line)
+	}
+}
+
+func (c *Conf) programName() string {
+	if len(c.program) != 0 {
+		return c.program
+	}
+
+	if len(os.Args) != 0 {
+		return filepath.Base(os.Args[0])
+	}
+
+	return "app"
+}
+
+func (c *Conf) programSynopsis() string {
+	if len(c.synopsis) != 0 {
+		return c.synopsis
+	}
+
+	return "[options]"
+}
+
+//sortedOptions returns options in declaration order
+func (c *Conf) sortedOptions() []*Option {
+	options := make([]*Option, 0, len(c.options))
+
+	for _, opt := range c.options {
+		options = append(options, opt)
+	}
+
+	sort.Slice(options, func(i, k int) bool {
+		if options[i].index != options[k].index {
+			return options[i].index < options[k].index
+		}
+		return options[i].key.fullName < options[k].key.fullName
+	})
+
+	return options
+}
+
+//usageSources returns environment name and JSON path of option, if Conf has such middlewares
+func (c *Conf) usageSources(opt *Option) string {
+	sources := make([]string, 0, 2)
+
+	if env, isOk := c.optionEnvName(opt); isOk {
+		sources = append(sources, "env: "+env)
+	}
+
+	if path, isOk := c.optionJSONPath(opt); isOk {
+		sources = append(sources, "json: "+path)
+	}
+
+	return strings.Join(sources, ", ")
+}
+
+//optionEnvName returns environment variable name of option, if Conf has Env middleware
+func (c *Conf) optionEnvName(opt *Option) (string, bool) {
+	if len(opt.key.fullName) == 0 || opt.builtin {
+		return "", false
+	}
+
+	for _, m := range c.middleware {
+		if env, isOk := m.(*Env); isOk {
+			if env.upperCaseNames || c.isProfileOption(opt) {
+				return env.prefix + EnvName(opt.key.fullName), true
+			}
+
+			return env.prefix + opt.key.fullName, true
+		}
+	}
+
+	return "", false
+}
+
+//optionJSONPath returns JSON path of option, if Conf has JSON middleware
+func (c *Conf) optionJSONPath(opt *Option) (string, bool) {
+	if len(opt.key.fullName) == 0 || opt.builtin {
+		return "", false
+	}
+
+	for _, m := range c.middleware {
+		switch m.(type) {
+		case *JSON, *JSONFiles:
+			return opt.key.fullName, true
+		}
+	}
+
+	return "", false
+}
+
+//usageFlag returns option flags with value placeholder, like `-p, --port=<int>`
+func (o *Option) usageFlag() string {
+	names := make([]string, 0, 2)
+
+	if len(o.key.shortName) != 0 {
+		names = append(names, "-"+o.key.shortName)
+	}
+
+	if len(o.key.fullName) != 0 {
+		names = append(names, "--"+o.key.fullName)
+	}
+
+	flag := strings.Join(names, ", ")
+
+	switch o.optionType {
+	case existenceType:
+		return flag
+	case sliceType:
+		return flag + "[]=<value>"
+	}
+
+	return flag + "=<" + o.optionType.String() + ">"
+}
+
+//usageDefault returns default value for usage, empty values are not shown
+func (o *Option) usageDefault() string {
+	switch v := o.defaultValue.(type) {
+	case nil:
+		return ""
+	case string:
+		if len(v) == 0 {
+			return ""
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return ""
+		}
+	case bool:
+		if o.optionType == existenceType {
+			return ""
+		}
+	}
+
+	return o.DisplayDefault()
+}
+
+//usageWidth returns terminal width from $COLUMNS or default width
+func usageWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	return defaultUsageWidth
+}
+
+//wrapText splits text to lines, that are not longer than width, if words allow it
+func wrapText(text string, width int) []string {
+	words := strings.Fields(text)
+	lines := make([]string, 0)
+
+	if width < 20 {
+		width = 20
+	}
+
+	line := ""
+
+	for _, word := range words {
+		if len(line) != 0 && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+
+		if len(line) != 0 {
+			line += " "
+		}
+
+		line += word
+	}
+
+	if len(line) != 0 {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
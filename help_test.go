@@ -0,0 +1,68 @@
+package comfyconf
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConf_WriteUsage(t *testing.T) {
+	_ = os.Setenv("COLUMNS", "80")
+	defer os.Unsetenv("COLUMNS")
+
+	env := NewEnv()
+	env.SetUpperCaseNames(true)
+
+	conf := New(NewJSON("config.json"), env, NewFlags())
+	conf.SetProgram("server", "[options] <command>")
+
+	conf.String("", "db.host", "localhost", "Database host")
+	conf.Int("p", "db.port", 5432, "Database port")
+	conf.Secret("", "db.password", "changeme", "Database password")
+	conf.Exist("v", "verbose", "Verbose output, that is shown on every request and can be very long and noisy")
+	conf.Slice("t", "tags", nil, "Tags")
+
+	conf.SetGroup("Database", "db.host", "db.port", "db.password")
+
+	var buffer bytes.Buffer
+	assert.NoError(t, conf.WriteUsage(&buffer))
+
+	assert.Equal(t, `Usage: server [options] <command>
+
+Options:
+  -v, --verbose           Verbose output, that is shown on every request and can
+                          be very long and noisy
+                          env: ENV_VERBOSE, json: verbose
+  -t, --tags[]=<value>    Tags
+                          env: ENV_TAGS, json: tags
+
+Database:
+  --db.host=<string>      Database host (default: "localhost")
+                          env: ENV_DB_HOST, json: db.host
+  -p, --db.port=<int>     Database port (default: 5432)
+                          env: ENV_DB_PORT, json: db.port
+  --db.password=<string>  Database password (default: ******)
+                          env: ENV_DB_PASSWORD, json: db.password
+`, buffer.String())
+}
+
+func TestConf_WriteUsage_Flags(t *testing.T) {
+	conf := prepareConf([]string{}, "=")
+	conf.SetProgram("app", "")
+
+	conf.String("n", "a-very-long-option-name-that-does-not-fit", "", "Description")
+	conf.Bool("d", "", false, "Debug")
+
+	var buffer bytes.Buffer
+	assert.NoError(t, conf.WriteUsage(&buffer))
+
+	assert.Equal(t, `Usage: app [options]
+
+Options:
+  -n, --a-very-long-option-name-that-does-not-fit=<string>
+                                Description
+  -d=<bool>                     Debug (default: false)
+`, buffer.String())
+}
@@ -0,0 +1,326 @@
+package comfyconf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	//DefaultHTTPTimeout timeout of single request of HTTP middleware
+	DefaultHTTPTimeout = 10 * time.Second
+	//DefaultHTTPRetries number of retries of failed request of HTTP middleware
+	DefaultHTTPRetries = 2
+	//DefaultHTTPRetryDelay delay before first retry, every next retry waits twice longer
+	DefaultHTTPRetryDelay = 500 * time.Millisecond
+	//DefaultHTTPMaxSize size limit in bytes of response body of HTTP middleware
+	DefaultHTTPMaxSize = 10 << 20
+)
+
+//NewHTTP returns pointer to instance of middleware, that fetches JSON or YAML configuration from URL.
+//YAML is detected by `Content-Type` of response or by `.yaml` and `.yml` extension of URL
+func NewHTTP(url string) *HTTP {
+	h := &HTTP{
+		url:        url,
+		client:     http.DefaultClient,
+		timeout:    DefaultHTTPTimeout,
+		retries:    DefaultHTTPRetries,
+		retryDelay: DefaultHTTPRetryDelay,
+		maxSize:    DefaultHTTPMaxSize,
+		headers:    make(http.Header),
+	}
+
+	h.JSON = JSON{
+		path:   url,
+		reader: h.read,
+	}
+
+	return h
+}
+
+//HTTP structure implements Middleware for remote configuration. Conditional requests with `If-None-Match` and
+//`If-Modified-Since` headers are used, so unchanged configuration is not transferred again. When cache file is set,
+//latest fetched configuration is stored in it and is used, when endpoint is not available
+type HTTP struct {
+	JSON
+
+	mu         sync.Mutex
+	url        string
+	client     *http.Client
+	timeout    time.Duration
+	retries    int
+	retryDelay time.Duration
+	maxSize    int64
+	headers    http.Header
+	cacheFile  string
+
+	etag         string
+	lastModified string
+	contentType  string
+	content      []byte
+}
+
+//httpCache content of cache file of HTTP middleware
+type httpCache struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	ContentType  string `json:"contentType,omitempty"`
+	Content      string `json:"content"`
+}
+
+//SetTimeout sets timeout of single request
+func (h *HTTP) SetTimeout(timeout time.Duration) {
+	h.timeout = timeout
+}
+
+//SetRetries sets number of retries after network errors and server errors and delay before first retry.
+//Every next retry waits twice longer. Client errors are not retried
+func (h *HTTP) SetRetries(retries int, delay time.Duration) {
+	h.retries = retries
+	h.retryDelay = delay
+}
+
+//SetMaxSize sets size limit in bytes of response body. Larger configuration is rejected
+func (h *HTTP) SetMaxSize(limit int64) {
+	h.maxSize = limit
+}
+
+//SetCache sets file, where latest fetched configuration is stored. Cached configuration is used,
+//when endpoint is not available
+func (h *HTTP) SetCache(file string) {
+	h.cacheFile = file
+}
+
+//SetHeader sets header of every request, like `Authorization`
+func (h *HTTP) SetHeader(key string, value string) {
+	h.headers.Set(key, value)
+}
+
+//SetClient sets HTTP client, that is used for requests. http.DefaultClient is used by default
+func (h *HTTP) SetClient(client *http.Client) {
+	h.client = client
+}
+
+//Name returns name of HTTP middleware source
+func (h *HTTP) Name() string {
+	return "http:" + h.url
+}
+
+//Origin is not reported, HTTP configuration has no includes
+func (h *HTTP) Origin(shortName string, fullName string) (string, bool) {
+	return "", false
+}
+
+//Save is not supported for remote configuration
+func (h *HTTP) Save(values map[string]interface{}) error {
+	return fmt.Errorf("comfyconf: %s: saving is not supported", h.Name())
+}
+
+//Changed fetches configuration with conditional request and reports whether it differs from latest fetched one.
+//Used by Conf.Watch for polling
+func (h *HTTP) Changed() (bool, error) {
+	h.mu.Lock()
+	previous := h.content
+	h.mu.Unlock()
+
+	content, _, err := h.fetch()
+
+	if err != nil {
+		return false, err
+	}
+
+	return !bytes.Equal(previous, content), nil
+}
+
+//read is reader of embedded JSON middleware. Includes and profile files are not fetched
+func (h *HTTP) read(j *JSON) ([]byte, error) {
+	if j.path != h.url {
+		return nil, &os.PathError{Op: "fetch", Path: j.path, Err: os.ErrNotExist}
+	}
+
+	content, contentType, err := h.fetch()
+
+	//stale content is used, when endpoint is not available
+	if content == nil {
+		return nil, err
+	}
+
+	if !h.isYAML(contentType) {
+		return content, nil
+	}
+
+	converted, err := yamlToJSON(content)
+
+	if err != nil {
+		return nil, fmt.Errorf("comfyconf: %s: %v", h.url, err)
+	}
+
+	return converted, nil
+}
+
+func (h *HTTP) isYAML(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return true
+	case "application/json", "text/json":
+		return false
+	}
+
+	if parsed, err := url.Parse(h.url); err == nil {
+		switch strings.ToLower(path.Ext(parsed.Path)) {
+		case ".yaml", ".yml":
+			return true
+		}
+	}
+
+	return false
+}
+
+//fetch returns latest configuration and its content type. When all attempts fail, previously fetched or cached
+//content is returned together with error
+func (h *HTTP) fetch() ([]byte, string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.content == nil && len(h.cacheFile) != 0 {
+		h.loadCache()
+	}
+
+	var err error
+
+	for attempt := 0; attempt <= h.retries; attempt++ {
+		//lock is released during delay, so Changed and Init of other goroutines are not blocked by retries
+		if attempt != 0 {
+			h.mu.Unlock()
+			time.Sleep(h.retryDelay << uint(attempt-1))
+			h.mu.Lock()
+		}
+
+		var retry bool
+
+		retry, err = h.request()
+
+		if err == nil || !retry {
+			break
+		}
+	}
+
+	return h.content, h.contentType, err
+}
+
+//request performs single conditional request. Returns whether failed request can be retried
+func (h *HTTP) request() (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	request, err := http.NewRequest(http.MethodGet, h.url, nil)
+
+	if err != nil {
+		return false, fmt.Errorf("comfyconf: %s: %v", h.url, err)
+	}
+
+	for key, values := range h.headers {
+		request.Header[key] = values
+	}
+
+	if h.content != nil {
+		if len(h.etag) != 0 {
+			request.Header.Set("If-None-Match", h.etag)
+		}
+
+		if len(h.lastModified) != 0 {
+			request.Header.Set("If-Modified-Since", h.lastModified)
+		}
+	}
+
+	response, err := h.client.Do(request.WithContext(ctx))
+
+	if err != nil {
+		return true, fmt.Errorf("comfyconf: %s: %v", h.url, err)
+	}
+
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusNotModified && h.content != nil:
+		return false, nil
+	case response.StatusCode >= http.StatusInternalServerError || response.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("comfyconf: %s: unexpected status %s", h.url, response.Status)
+	case response.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("comfyconf: %s: unexpected status %s", h.url, response.Status)
+	}
+
+	content, err := ioutil.ReadAll(io.LimitReader(response.Body, h.maxSize+1))
+
+	if err != nil {
+		return true, fmt.Errorf("comfyconf: %s: %v", h.url, err)
+	}
+
+	if int64(len(content)) > h.maxSize {
+		return false, fmt.Errorf("comfyconf: %s: response exceeds size limit of %d bytes", h.url, h.maxSize)
+	}
+
+	h.content = content
+	h.contentType = response.Header.Get("Content-Type")
+	h.etag = response.Header.Get("ETag")
+	h.lastModified = response.Header.Get("Last-Modified")
+
+	//failed cache write does not break configuration, it is written again after next change
+	h.saveCache()
+
+	return false, nil
+}
+
+//loadCache restores latest fetched configuration from cache file, cache of other URL is ignored
+func (h *HTTP) loadCache() {
+	content, err := ioutil.ReadFile(h.cacheFile)
+
+	if err != nil {
+		return
+	}
+
+	var cache httpCache
+
+	if json.Unmarshal(content, &cache) != nil || cache.URL != h.url {
+		return
+	}
+
+	h.content = []byte(cache.Content)
+	h.contentType = cache.ContentType
+	h.etag = cache.ETag
+	h.lastModified = cache.LastModified
+}
+
+func (h *HTTP) saveCache() error {
+	if len(h.cacheFile) == 0 {
+		return nil
+	}
+
+	content, err := json.Marshal(httpCache{
+		URL:          h.url,
+		ETag:         h.etag,
+		LastModified: h.lastModified,
+		ContentType:  h.contentType,
+		Content:      string(h.content),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(h.cacheFile, content, 0600)
+}
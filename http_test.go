@@ -0,0 +1,269 @@
+package comfyconf
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//configServer serves configuration with ETag and counts requests
+type configServer struct {
+	mu          sync.Mutex
+	content     string
+	contentType string
+	etag        string
+	status      int
+	requests    int
+	notModified int
+	header      http.Header
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	s.header = r.Header
+
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+
+	if r.Header.Get("If-None-Match") == s.etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", s.etag)
+	w.Header().Set("Content-Type", s.contentType)
+	w.Write([]byte(s.content))
+}
+
+func (s *configServer) set(content string, etag string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.content = content
+	s.etag = etag
+	s.status = status
+}
+
+func TestHTTP_Init(t *testing.T) {
+	s := &configServer{content: `{"db": {"port": 5432}, "name": "app"}`, contentType: "application/json", etag: `"v1"`}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	h := NewHTTP(server.URL + "/config")
+	h.SetHeader("Authorization", "Bearer token")
+	assert.NoError(t, h.Init())
+	assert.Equal(t, "http:"+server.URL+"/config", h.Name())
+	assert.Equal(t, "Bearer token", s.header.Get("Authorization"))
+
+	port, isOk := h.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 5432, port)
+
+	name, isOk := h.ParseString("n", "name")
+	assert.True(t, isOk)
+	assert.Equal(t, "app", name)
+
+	assert.NoError(t, h.Init())
+	assert.Equal(t, `"v1"`, s.header.Get("If-None-Match"))
+	assert.Equal(t, 1, s.notModified)
+
+	port, isOk = h.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 5432, port)
+
+	assert.Error(t, h.Save(map[string]interface{}{"name": "other"}))
+}
+
+func TestHTTP_InitYAML(t *testing.T) {
+	s := &configServer{content: "db:\n  port: 5432\nhosts:\n  - a\n  - b\n", contentType: "application/yaml", etag: `"v1"`}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	h := NewHTTP(server.URL + "/config")
+	assert.NoError(t, h.Init())
+
+	port, isOk := h.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 5432, port)
+
+	hosts, isOk := h.ParseSlice("h", "hosts")
+	assert.True(t, isOk)
+	assert.Equal(t, []interface{}{"a", "b"}, hosts)
+
+	s.contentType = "text/plain"
+	h = NewHTTP(server.URL + "/config.yml")
+	assert.NoError(t, h.Init())
+
+	port, isOk = h.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 5432, port)
+}
+
+func TestHTTP_InitRetries(t *testing.T) {
+	s := &configServer{status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	h := NewHTTP(server.URL)
+	h.SetRetries(2, time.Millisecond)
+	assert.Error(t, h.Init())
+	assert.Equal(t, 3, s.requests)
+
+	s.set("", "", http.StatusNotFound)
+	s.requests = 0
+	assert.Error(t, h.Init())
+	assert.Equal(t, 1, s.requests)
+}
+
+func TestHTTP_InitMaxSize(t *testing.T) {
+	s := &configServer{content: `{"name": "app"}`, etag: "v1"}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	h := NewHTTP(server.URL)
+	h.SetMaxSize(15)
+	assert.NoError(t, h.Init())
+
+	s.set(`{"name": "application"}`, "v2", 0)
+	s.requests = 0
+
+	_, err := h.Changed()
+	assert.EqualError(t, err, "comfyconf: "+server.URL+": response exceeds size limit of 15 bytes")
+	assert.Equal(t, 1, s.requests)
+
+	h = NewHTTP(server.URL)
+	h.SetMaxSize(15)
+	assert.EqualError(t, h.Init(), "comfyconf: "+server.URL+": response exceeds size limit of 15 bytes")
+}
+
+func TestHTTP_InitTimeout(t *testing.T) {
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer server.Close()
+	defer close(block)
+
+	h := NewHTTP(server.URL)
+	h.SetTimeout(10 * time.Millisecond)
+	h.SetRetries(0, 0)
+
+	started := time.Now()
+	assert.Error(t, h.Init())
+	assert.True(t, time.Since(started) < 5*time.Second)
+}
+
+func TestHTTP_Cache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comfyconf")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cacheFile := filepath.Join(dir, "cache.json")
+
+	s := &configServer{content: `{"name": "app"}`, contentType: "application/json", etag: `"v1"`}
+	server := httptest.NewServer(s)
+
+	h := NewHTTP(server.URL)
+	h.SetCache(cacheFile)
+	assert.NoError(t, h.Init())
+
+	_, err = os.Stat(cacheFile)
+	assert.NoError(t, err)
+
+	//cached content is revalidated with conditional request
+	h = NewHTTP(server.URL)
+	h.SetCache(cacheFile)
+	assert.NoError(t, h.Init())
+	assert.Equal(t, 1, s.notModified)
+
+	server.Close()
+
+	h = NewHTTP(server.URL)
+	h.SetCache(cacheFile)
+	h.SetRetries(0, 0)
+	assert.NoError(t, h.Init())
+
+	name, isOk := h.ParseString("n", "name")
+	assert.True(t, isOk)
+	assert.Equal(t, "app", name)
+
+	h = NewHTTP(server.URL)
+	h.SetRetries(0, 0)
+	assert.Error(t, h.Init())
+}
+
+func TestHTTP_Changed(t *testing.T) {
+	s := &configServer{content: `{"name": "app"}`, contentType: "application/json", etag: `"v1"`}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	h := NewHTTP(server.URL)
+	h.SetRetries(0, 0)
+	assert.NoError(t, h.Init())
+
+	changed, err := h.Changed()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	s.set(`{"name": "other"}`, `"v2"`, 0)
+
+	changed, err = h.Changed()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	s.set(`{"name": "other"}`, `"v2"`, http.StatusBadGateway)
+
+	changed, err = h.Changed()
+	assert.Error(t, err)
+	assert.False(t, changed)
+}
+
+func TestHTTP_Watch(t *testing.T) {
+	s := &configServer{content: `{"name": "app"}`, contentType: "application/json", etag: `"v1"`}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	h := NewHTTP(server.URL)
+	c := New(h)
+	name := c.String("n", "name", "default", "Name")
+	assert.NoError(t, c.Parse())
+	assert.Equal(t, "app", *name)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	reloaded := make(chan error, 1)
+
+	c.Watch(5*time.Millisecond, stop, func(err error) {
+		select {
+		case reloaded <- err:
+		default:
+		}
+	})
+
+	s.set(`{"name": "other"}`, `"v2"`, 0)
+
+	select {
+	case err := <-reloaded:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("configuration was not reloaded")
+	}
+
+	c.View(func() {
+		assert.Equal(t, "other", *name)
+	})
+}
@@ -0,0 +1,197 @@
+package comfyconf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	interpolationEnvPrefix = "env:"
+	interpolationFallback  = ":-"
+)
+
+//SetInterpolation enables or disables interpolation of string values after all middlewares are parsed.
+//`${other.option}` is replaced by value of other option, `${env:HOME}` by environment variable and
+//`${name:-default}` uses default, when referenced value is empty or not defined. `$${` is used for literal `${`.
+//Secret options can be referenced only by other secret options
+func (c *Conf) SetInterpolation(enabled bool) {
+	c.interpolation = enabled
+}
+
+type interpolator struct {
+	conf  *Conf
+	state map[*Option]int
+	stack []string
+}
+
+const (
+	interpolationVisiting = iota + 1
+	interpolationDone
+)
+
+func (c *Conf) interpolate() error {
+	i := &interpolator{
+		conf:  c,
+		state: make(map[*Option]int),
+	}
+
+	for _, opt := range c.options {
+		if err := i.resolve(opt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//resolve expands references in option value, referenced options are resolved first
+func (i *interpolator) resolve(opt *Option) error {
+	switch i.state[opt] {
+	case interpolationDone:
+		return nil
+	case interpolationVisiting:
+		return fmt.Errorf("comfyconf: option %q: interpolation cycle %s -> %s",
+			i.stack[len(i.stack)-1], strings.Join(i.stack, " -> "), opt.key.fullName)
+	}
+
+	i.state[opt] = interpolationVisiting
+	i.stack = append(i.stack, opt.key.fullName)
+
+	switch opt.optionType {
+	case stringType:
+		v, err := i.expand(opt.GetValue().(string), opt)
+
+		if err != nil {
+			return err
+		}
+
+		opt.Put(v)
+	case sliceType:
+		values := opt.GetValue().([]interface{})
+		expanded := make([]interface{}, len(values))
+
+		for k, item := range values {
+			expanded[k] = item
+
+			if s, isOk := item.(string); isOk {
+				v, err := i.expand(s, opt)
+
+				if err != nil {
+					return err
+				}
+
+				expanded[k] = v
+			}
+		}
+
+		if values != nil {
+			opt.Put(expanded)
+		}
+	}
+
+	i.stack = i.stack[:len(i.stack)-1]
+	i.state[opt] = interpolationDone
+
+	return nil
+}
+
+//expand replaces all references in value
+func (i *interpolator) expand(value string, opt *Option) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+
+	var buffer bytes.Buffer
+
+	for k := 0; k < len(value); k++ {
+		if strings.HasPrefix(value[k:], "$${") {
+			buffer.WriteString("${")
+			k += 2
+			continue
+		}
+
+		if !strings.HasPrefix(value[k:], "${") {
+			buffer.WriteByte(value[k])
+			continue
+		}
+
+		end := matchingBrace(value, k+2)
+
+		if end < 0 {
+			return "", fmt.Errorf("comfyconf: option %q: unterminated reference in value", opt.key.fullName)
+		}
+
+		v, err := i.reference(value[k+2:end], opt)
+
+		if err != nil {
+			return "", err
+		}
+
+		buffer.WriteString(v)
+		k = end
+	}
+
+	return buffer.String(), nil
+}
+
+//reference returns value of single reference expression without surrounding `${` and `}`
+func (i *interpolator) reference(expr string, opt *Option) (string, error) {
+	name := expr
+	fallback := ""
+	hasFallback := false
+
+	if index := strings.Index(expr, interpolationFallback); index >= 0 {
+		name = expr[:index]
+		fallback = expr[index+len(interpolationFallback):]
+		hasFallback = true
+	}
+
+	var value string
+
+	if strings.HasPrefix(name, interpolationEnvPrefix) {
+		value = os.Getenv(strings.TrimPrefix(name, interpolationEnvPrefix))
+	} else if referenced := i.conf.Lookup(name); referenced != nil {
+		//secret value expanded into not secret option would be revealed by help, export and provenance
+		if referenced.secret && !opt.secret {
+			return "", fmt.Errorf("comfyconf: option %q: reference to secret option %q, option must be secret too",
+				opt.key.fullName, name)
+		}
+
+		if err := i.resolve(referenced); err != nil {
+			return "", err
+		}
+
+		value = fmt.Sprintf("%v", referenced.GetValue())
+	} else if !hasFallback {
+		return "", fmt.Errorf("comfyconf: option %q: reference to unknown option %q", opt.key.fullName, name)
+	}
+
+	if len(value) == 0 && hasFallback {
+		return i.expand(fallback, opt)
+	}
+
+	return value, nil
+}
+
+//matchingBrace returns index of brace, that closes reference started before start index, or -1
+func matchingBrace(value string, start int) int {
+	depth := 1
+
+	for k := start; k < len(value); k++ {
+		switch {
+		case strings.HasPrefix(value[k:], "${"):
+			depth++
+			k++
+		case value[k] == '}':
+			depth--
+
+			if depth == 0 {
+				return k
+			}
+		}
+	}
+
+	return -1
+}
@@ -2,11 +2,19 @@ package comfyconf
 
 import (
 	"encoding/json"
+	"fmt"
+	"io/fs"
 	"io/ioutil"
+	"math"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+const includeKey = "$include"
+
 //NewJSON returns pointer to instance of JSON configuration middleware
 //with default file from where JSON will be read
 func NewJSON(file string) *JSON {
@@ -65,50 +73,342 @@ type JSON struct {
 
 	parsed     map[string]interface{}
 	shortIndex map[string]string
+	origins    map[string]valueOrigin
+	schema     map[string]interface{}
+	tree       map[string]interface{}
+
+	strict       bool
+	syntax       Syntax
+	sources      map[fileID]*jsonSource
+	positions    map[fileID]map[string]int
+	strictErrors []error
+
+	shortNames ShortNameMode
+	collisions map[string][]string
+
+	decrypter Decrypter
+
+	fsys        fs.FS
+	filesystems []fs.FS
+
+	format string
 }
 
-//DefaultJSONReader default JSON file reader
+//DefaultJSONReader default JSON file reader. Reads file from filesystem of middleware, when it is set
 func DefaultJSONReader(j *JSON) ([]byte, error) {
+	if j.fsys != nil {
+		return fs.ReadFile(j.fsys, fsPath(j.path))
+	}
+
 	return ioutil.ReadFile(j.path)
 }
 
+//FormatReader reads file same way as DefaultJSONReader and converts YAML and TOML files to JSON
+//by their extension
+func FormatReader(j *JSON) ([]byte, error) {
+	content, err := DefaultJSONReader(j)
+
+	if err != nil {
+		return nil, err
+	}
+
+	convert := converter(j.path)
+
+	if convert == nil {
+		return content, nil
+	}
+
+	converted, err := convert(content)
+
+	if err != nil {
+		return nil, fmt.Errorf("comfyconf: %s: %v", j.path, err)
+	}
+
+	return converted, nil
+}
+
+//converter returns function, that converts file to JSON by its extension, or nil for JSON files
+func converter(path string) func(content []byte) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return yamlToJSON
+	case ".toml":
+		return tomlToJSON
+	}
+
+	return nil
+}
+
+//isConverted reports whether file is converted to JSON by FormatReader of YAML and TOML middlewares.
+//Positions in generated JSON do not match source, so they are not reported for such files
+func (j *JSON) isConverted(path string) bool {
+	return len(j.format) != 0 && converter(path) != nil
+}
+
+//Name returns name of JSON middleware source
+func (j *JSON) Name() string {
+	if len(j.path) == 0 {
+		return j.formatName()
+	}
+	return j.formatName() + ":" + j.path
+}
+
+//formatName returns format of source, that is converted to JSON, or `json`
+func (j *JSON) formatName() string {
+	if len(j.format) == 0 {
+		return "json"
+	}
+
+	return j.format
+}
+
 //Init initializing middleware for JSON configuration
 func (j *JSON) Init() error {
 
+	j.resetSources()
+
+	if j.fsys != nil {
+		j.filesystems = append(j.filesystems, j.fsys)
+	}
+
 	contentBytes, err := j.reader(j)
 
 	if err != nil {
 		return err
 	}
 
-	tmpParsed := make(map[string]interface{})
+	tmpParsed, origins, err := j.decode(j.rootFile(), contentBytes, []fileID{j.rootFile()})
 
-	err = json.Unmarshal(contentBytes, &tmpParsed)
+	if err != nil {
+		return err
+	}
+
+	err = j.validateSchema(tmpParsed, origins)
 
 	if err != nil {
 		return err
 	}
 
+	j.tree = tmpParsed
 	j.parsed = j.parse(tmpParsed)
+	j.origins = origins
 
 	j.prepareIndex()
 
 	return nil
 }
 
+//decode decodes JSON object and merges files from `$include` key into it. Included files are read by middleware reader
+//relative to including file and are merged in provided order, values of including file have highest priority.
+//Returns merged object and files, from where every flattened key was taken. Files are identified like by readFile
+func (j *JSON) decode(file fileID, content []byte, stack []fileID) (map[string]interface{}, map[string]valueOrigin, error) {
+	tmpParsed := make(map[string]interface{})
+	path := file.path
+
+	content, err := j.decryptFile(path, content)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	source, err := newJSONSource(content, j.syntax)
+
+	if err != nil {
+		return nil, nil, newJSONError(path, &jsonSource{content: content, decoded: content, converted: j.isConverted(path)}, err)
+	}
+
+	source.converted = j.isConverted(path)
+
+	err = json.Unmarshal(source.decoded, &tmpParsed)
+
+	if err != nil {
+		return nil, nil, newJSONError(path, source, err)
+	}
+
+	if j.sources != nil {
+		j.sources[file] = source
+	}
+
+	includes, err := includeList(path, tmpParsed[includeKey])
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	delete(tmpParsed, includeKey)
+
+	merged := make(map[string]interface{})
+	origins := make(map[string]valueOrigin)
+
+	for _, include := range includes {
+		includePath := include
+
+		if !filepath.IsAbs(includePath) && len(path) != 0 {
+			includePath = filepath.Join(filepath.Dir(path), includePath)
+		}
+
+		includeFile := file.related(includePath)
+
+		for _, parent := range stack {
+			if parent == includeFile {
+				return nil, nil, fmt.Errorf("comfyconf: include cycle %s -> %s", filePaths(stack, " -> "), includePath)
+			}
+		}
+
+		includeContent, err := j.readFile(includeFile)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("comfyconf: %s: include %q: %v", path, include, err)
+		}
+
+		included, includedOrigins, err := j.decode(includeFile, includeContent, append(stack[:len(stack):len(stack)], includeFile))
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		mergeJSON(merged, included, "", nil)
+
+		for key, origin := range includedOrigins {
+			origins[key] = origin
+		}
+	}
+
+	mergeJSON(merged, tmpParsed, "", nil)
+
+	for key := range j.parse(tmpParsed) {
+		if len(path) != 0 {
+			origins[key] = valueOrigin{file: file}
+		} else {
+			delete(origins, key)
+		}
+	}
+
+	return merged, origins, nil
+}
+
+//includeList returns list of included files from `$include` value, that can be string or array of strings
+func includeList(path string, value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		includes := make([]string, 0, len(v))
+
+		for _, item := range v {
+			include, isOk := item.(string)
+
+			if !isOk {
+				return nil, fmt.Errorf("comfyconf: %s: %s must contain only strings", path, includeKey)
+			}
+
+			includes = append(includes, include)
+		}
+
+		return includes, nil
+	}
+
+	return nil, fmt.Errorf("comfyconf: %s: %s must be string or array of strings", path, includeKey)
+}
+
+//Origin returns name of file, from where option value was taken, if it differs from middleware file
+func (j *JSON) Origin(shortName string, fullName string) (string, bool) {
+	key, isOk := j.key(shortName, fullName)
+
+	if !isOk {
+		return "", false
+	}
+
+	origin, isOk := j.origins[key]
+
+	if !isOk {
+		return "", false
+	}
+
+	return j.formatName() + ":" + origin.name(), true
+}
+
+//valueOrigin is file, from where value was taken, and section of that file, like `profiles.prod`,
+//when value was taken from profile section
+type valueOrigin struct {
+	file    fileID
+	section string
+}
+
+//name returns display name of origin, like `config.json` or `config.json#profiles.prod`
+func (o valueOrigin) name() string {
+	if len(o.section) == 0 {
+		return o.file.path
+	}
+
+	return o.file.path + "#" + o.section
+}
+
+//ShortNameMode defines how JSON middleware resolves option short names to keys of configuration
+type ShortNameMode int
+
+const (
+	//ShortNamesAll short name matches last segment of any key, like `host` for `db.host`
+	ShortNamesAll ShortNameMode = iota
+	//ShortNamesTopLevel short name matches only top-level keys
+	ShortNamesTopLevel
+	//ShortNamesOff options are resolved only by full names
+	ShortNamesOff
+)
+
+//SetShortNames sets how option short names are resolved. When short name matches several keys, top-level key
+//with same name is used, otherwise short name is not resolved at all and collision is reported
+func (j *JSON) SetShortNames(mode ShortNameMode) {
+	j.shortNames = mode
+}
+
+//ShortNameCollisions returns short names, that match several keys, with sorted list of matching keys
+func (j *JSON) ShortNameCollisions() map[string][]string {
+	collisions := make(map[string][]string, len(j.collisions))
+
+	for shortName, keys := range j.collisions {
+		collisions[shortName] = append([]string(nil), keys...)
+	}
+
+	return collisions
+}
+
 func (j *JSON) prepareIndex() {
 
 	j.shortIndex = make(map[string]string)
+	j.collisions = make(map[string][]string)
+
+	if j.shortNames == ShortNamesOff {
+		return
+	}
+
+	candidates := make(map[string][]string)
 
 	for k := range j.parsed {
 		s := strings.Split(k, ".")
 
-		if len(s) == 1 {
-			j.shortIndex[s[0]] = k
+		if len(s) != 1 && j.shortNames == ShortNamesTopLevel || isIndexedKey(k) {
 			continue
 		}
 
-		j.shortIndex[s[len(s)-1]] = k
+		candidates[s[len(s)-1]] = append(candidates[s[len(s)-1]], k)
+	}
+
+	for shortName, keys := range candidates {
+		if len(keys) == 1 {
+			j.shortIndex[shortName] = keys[0]
+			continue
+		}
+
+		sort.Strings(keys)
+		j.collisions[shortName] = keys
+
+		//top-level key has priority over nested keys
+		if _, isExist := j.parsed[shortName]; isExist {
+			j.shortIndex[shortName] = shortName
+		}
 	}
 }
 
@@ -129,6 +429,10 @@ func parseOne(tmp map[string]interface{}, key string, data map[string]interface{
 			k = key + "." + k
 		}
 
+		if v == nil {
+			continue
+		}
+
 		t := reflect.TypeOf(v).Kind()
 
 		if t == reflect.Slice {
@@ -140,6 +444,8 @@ func parseOne(tmp map[string]interface{}, key string, data map[string]interface{
 
 			tmp[k] = v1
 
+			parseItems(tmp, k, v1)
+
 			continue
 		}
 
@@ -159,25 +465,86 @@ func parseOne(tmp map[string]interface{}, key string, data map[string]interface{
 	}
 }
 
-func (j *JSON) get(shortName string, fullName string) (interface{}, bool) {
+//parseItems flattens items of array with indexed keys, like `servers.0.host`
+func parseItems(tmp map[string]interface{}, key string, items []interface{}) {
+	for i, item := range items {
+		k := key + "." + strconv.Itoa(i)
+
+		switch v := item.(type) {
+		case nil:
+		case map[string]interface{}:
+			parseOne(tmp, k, v)
+		case []interface{}:
+			tmp[k] = v
+			parseItems(tmp, k, v)
+		default:
+			tmp[k] = v
+		}
+	}
+}
 
-	var v interface{}
+//isIndexedKey returns true, if key addresses item of array
+func isIndexedKey(key string) bool {
+	for _, segment := range strings.Split(key, ".") {
+		if _, err := strconv.Atoi(segment); err == nil {
+			return true
+		}
+	}
 
-	v = j.parsed[fullName]
+	return false
+}
 
-	if v != nil {
-		return v, true
+//Lookup returns value by JSON Pointer, like `/servers/0/host`, or by full name, like `servers.0.host`.
+//Objects are not returned, only values and arrays
+func (j *JSON) Lookup(path string) (interface{}, bool) {
+	if strings.HasPrefix(path, "/") {
+		path = pointerKey(path)
 	}
 
-	v = j.parsed[j.shortIndex[shortName]]
+	v, isOk := j.parsed[path]
 
-	if v != nil {
-		return v, true
+	return v, isOk && v != nil
+}
+
+//key returns key of parsed value by full name or by short name
+func (j *JSON) key(shortName string, fullName string) (string, bool) {
+
+	if strings.HasPrefix(fullName, "/") {
+		fullName = pointerKey(fullName)
+	}
+
+	if j.parsed[fullName] != nil {
+		return fullName, true
+	}
+
+	if key, isOk := j.shortIndex[shortName]; isOk && j.parsed[key] != nil {
+		return key, true
 	}
 
 	return "", false
 }
 
+//mustKey returns key of existing parsed value
+func (j *JSON) mustKey(shortName string, fullName string) string {
+	key, _ := j.key(shortName, fullName)
+	return key
+}
+
+func (j *JSON) get(shortName string, fullName string) (interface{}, bool) {
+
+	key, isOk := j.key(shortName, fullName)
+
+	if !isOk {
+		if _, isAmbiguous := j.collisions[shortName]; isAmbiguous && j.parsed[fullName] == nil {
+			j.reportAmbiguous(shortName)
+		}
+
+		return "", false
+	}
+
+	return j.parsed[key], true
+}
+
 //ParseInt tries to get int from JSON configuration
 func (j *JSON) ParseInt(shortName string, fullName string) (int, bool) {
 
@@ -193,9 +560,20 @@ func (j *JSON) ParseInt(shortName string, fullName string) (int, bool) {
 		return int(v1), isOk
 	case float64:
 		v1, isOk := v.(float64)
+
+		if j.strict && v1 != math.Trunc(v1) {
+			j.reportType(j.mustKey(shortName, fullName), intType, v)
+			return 0, false
+		}
+
 		return int(v1), isOk
 	default:
 		v1, isOk := v.(int)
+
+		if !isOk {
+			j.reportType(j.mustKey(shortName, fullName), intType, v)
+		}
+
 		return v1, isOk
 	}
 }
@@ -209,6 +587,24 @@ func (j *JSON) ParseString(shortName string, fullName string) (string, bool) {
 	}
 
 	v1, isOk := v.(string)
+
+	if !isOk {
+		j.reportType(j.mustKey(shortName, fullName), stringType, v)
+	}
+
+	return v1, isOk
+}
+
+//lookupString returns string value without reporting type error, values of other types are skipped
+func (j *JSON) lookupString(shortName string, fullName string) (string, bool) {
+	v, isOk := j.get(shortName, fullName)
+
+	if !isOk {
+		return "", false
+	}
+
+	v1, isOk := v.(string)
+
 	return v1, isOk
 }
 
@@ -221,12 +617,21 @@ func (j *JSON) ParseBool(shortName string, fullName string) (bool, bool) {
 	}
 
 	v1, isOk := v.(bool)
+
+	if !isOk {
+		j.reportType(j.mustKey(shortName, fullName), boolType, v)
+	}
+
 	return v1, isOk
 }
 
 //ParseExistence tries to check that element exists in JSON configuration
 func (j *JSON) ParseExistence(shortName string, fullName string) (bool, bool) {
 
+	if strings.HasPrefix(fullName, "/") {
+		fullName = pointerKey(fullName)
+	}
+
 	if j.parsed[fullName] != nil {
 		return true, true
 	}
@@ -247,5 +652,9 @@ func (j *JSON) ParseSlice(shortName string, fullName string) ([]interface{}, boo
 
 	v1, isOk := v.([]interface{})
 
+	if !isOk {
+		j.reportType(j.mustKey(shortName, fullName), sliceType, v)
+	}
+
 	return v1, isOk
 }
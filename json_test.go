@@ -158,3 +158,190 @@ func TestJson_ParseSlice(t *testing.T) {
 	assert.True(t, isOk)
 	assert.Equal(t, "Villian.zip", v[0])
 }
+
+func TestJson_Init_Include(t *testing.T) {
+	files := map[string]string{
+		"/etc/app/config.json":         `{"$include": ["db.json", "conf.d/logging.json"], "db": {"port": 5433}}`,
+		"/etc/app/db.json":             `{"db": {"host": "db.local", "port": 5432}}`,
+		"/etc/app/conf.d/logging.json": `{"$include": "../level.json", "logging": {"file": "/var/log/app.log"}}`,
+		"/etc/app/level.json":          `{"logging": {"level": "debug"}}`,
+	}
+
+	jp := NewJSONWithCustomReader(func(j *JSON) ([]byte, error) {
+		if len(j.path) == 0 {
+			return []byte(`{"$include": "/etc/app/config.json", "logging": {"level": "info"}}`), nil
+		}
+
+		content, isOk := files[j.path]
+		if !isOk {
+			return nil, os.ErrNotExist
+		}
+		return []byte(content), nil
+	})
+
+	assert.NoError(t, jp.Init())
+
+	host, isOk := jp.ParseString("host", "db.host")
+	assert.True(t, isOk)
+	assert.Equal(t, "db.local", host)
+
+	port, isOk := jp.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 5433, port)
+
+	level, isOk := jp.ParseString("level", "logging.level")
+	assert.True(t, isOk)
+	assert.Equal(t, "info", level)
+
+	file, isOk := jp.ParseString("file", "logging.file")
+	assert.True(t, isOk)
+	assert.Equal(t, "/var/log/app.log", file)
+
+	origin, isOk := jp.Origin("host", "db.host")
+	assert.True(t, isOk)
+	assert.Equal(t, "json:/etc/app/db.json", origin)
+
+	_, isOk = jp.Origin("level", "logging.level")
+	assert.False(t, isOk)
+
+	_, isOk = jp.ParseString(includeKey, includeKey)
+	assert.False(t, isOk)
+}
+
+func TestJson_Init_Include_Errors(t *testing.T) {
+	files := map[string]string{
+		"/a.json": `{"$include": "b.json"}`,
+		"/b.json": `{"$include": ["a.json"]}`,
+		"/c.json": `{"$include": 1}`,
+		"/d.json": `{"$include": "missing.json"}`,
+	}
+
+	reader := func(j *JSON) ([]byte, error) {
+		content, isOk := files[j.path]
+		if !isOk {
+			return nil, os.ErrNotExist
+		}
+		return []byte(content), nil
+	}
+
+	jp := NewJSON("/a.json")
+	jp.reader = reader
+
+	err := jp.Init()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "include cycle /a.json -> /b.json -> /a.json")
+
+	jp = NewJSON("/c.json")
+	jp.reader = reader
+	assert.Error(t, jp.Init())
+
+	jp = NewJSON("/d.json")
+	jp.reader = reader
+
+	err = jp.Init()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "missing.json")
+}
+
+func TestJson_ShortNames(t *testing.T) {
+	reader := func(j *JSON) ([]byte, error) {
+		return []byte(`{"db": {"host": "db.local", "port": 5432}, "cache": {"host": "cache.local"},
+			"timeout": 5, "http": {"timeout": 10}, "level": "info"}`), nil
+	}
+
+	jp := NewJSONWithCustomReader(reader)
+	assert.NoError(t, jp.Init())
+
+	assert.Equal(t, map[string][]string{
+		"host":    {"cache.host", "db.host"},
+		"timeout": {"http.timeout", "timeout"},
+	}, jp.ShortNameCollisions())
+
+	_, isOk := jp.ParseString("host", "")
+	assert.False(t, isOk)
+
+	timeout, isOk := jp.ParseInt("timeout", "")
+	assert.True(t, isOk)
+	assert.Equal(t, 5, timeout)
+
+	port, isOk := jp.ParseInt("port", "")
+	assert.True(t, isOk)
+	assert.Equal(t, 5432, port)
+
+	jp = NewJSONWithCustomReader(reader)
+	jp.SetShortNames(ShortNamesTopLevel)
+	assert.NoError(t, jp.Init())
+
+	_, isOk = jp.ParseInt("port", "")
+	assert.False(t, isOk)
+
+	level, isOk := jp.ParseString("level", "")
+	assert.True(t, isOk)
+	assert.Equal(t, "info", level)
+	assert.Empty(t, jp.ShortNameCollisions())
+
+	jp = NewJSONWithCustomReader(reader)
+	jp.SetShortNames(ShortNamesOff)
+	assert.NoError(t, jp.Init())
+
+	_, isOk = jp.ParseString("level", "")
+	assert.False(t, isOk)
+
+	host, isOk := jp.ParseString("host", "db.host")
+	assert.True(t, isOk)
+	assert.Equal(t, "db.local", host)
+}
+
+func TestJson_ShortNames_Strict(t *testing.T) {
+	jp := NewJSONWithCustomReader(func(j *JSON) ([]byte, error) {
+		return []byte(`{"db": {"host": "db.local"}, "cache": {"host": "cache.local"}}`), nil
+	})
+	jp.SetStrict(true)
+
+	conf := New(jp)
+	conf.String("host", "", "", "Host")
+
+	err := conf.Parse()
+	assert.EqualError(t, err, `comfyconf: short name "host" is ambiguous: cache.host, db.host`)
+
+	conf = New(jp)
+	conf.String("host", "db.host", "", "Host")
+
+	assert.NoError(t, conf.Parse())
+}
+
+func TestJson_IndexedPaths(t *testing.T) {
+	jp := NewJSONWithCustomReader(func(j *JSON) ([]byte, error) {
+		return []byte(`{"servers": [{"host": "a.local", "port": 80}, {"host": "b.local", "tags": ["x", "y"]}],
+			"matrix": [[1, 2], [3]], "host": "main.local"}`), nil
+	})
+	assert.NoError(t, jp.Init())
+
+	host, isOk := jp.ParseString("", "servers.1.host")
+	assert.True(t, isOk)
+	assert.Equal(t, "b.local", host)
+
+	port, isOk := jp.ParseInt("", "/servers/0/port")
+	assert.True(t, isOk)
+	assert.Equal(t, 80, port)
+
+	tags, isOk := jp.ParseSlice("", "servers.1.tags")
+	assert.True(t, isOk)
+	assert.Equal(t, []interface{}{"x", "y"}, tags)
+
+	v, isOk := jp.Lookup("/matrix/0/1")
+	assert.True(t, isOk)
+	assert.Equal(t, float64(2), v)
+
+	v, isOk = jp.Lookup("matrix.1")
+	assert.True(t, isOk)
+	assert.Equal(t, []interface{}{float64(3)}, v)
+
+	_, isOk = jp.Lookup("/servers/2/host")
+	assert.False(t, isOk)
+
+	host, isOk = jp.ParseString("host", "")
+	assert.True(t, isOk)
+	assert.Equal(t, "main.local", host)
+	assert.Empty(t, jp.ShortNameCollisions())
+}
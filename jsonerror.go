@@ -0,0 +1,260 @@
+package comfyconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//JSONError error of malformed JSON configuration with position of error in file and source snippet
+type JSONError struct {
+	File    string
+	Line    int
+	Column  int
+	Snippet string
+	Message string
+}
+
+//Error returns error message with position, followed by source line and column marker
+func (e *JSONError) Error() string {
+	message := fmt.Sprintf("comfyconf: %s: %s", position(e.File, e.Line, e.Column), e.Message)
+
+	if len(e.Snippet) == 0 {
+		return message
+	}
+
+	return message + "\n" + e.Snippet
+}
+
+//TypeError error of option value, that has unexpected type in JSON configuration. Reported in strict mode
+type TypeError struct {
+	Key      string
+	File     string
+	Line     int
+	Column   int
+	Expected string
+	Actual   string
+}
+
+//Error returns error message, like `comfyconf: config.json:3:13: db.port: expected int, got string`
+func (e *TypeError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("comfyconf: %s: expected %s, got %s", e.Key, e.Expected, e.Actual)
+	}
+
+	return fmt.Sprintf("comfyconf: %s: %s: expected %s, got %s", position(e.File, e.Line, e.Column), e.Key, e.Expected, e.Actual)
+}
+
+//AmbiguousNameError error of short name, that matches several keys of JSON configuration. Reported in strict mode
+type AmbiguousNameError struct {
+	ShortName string
+	Keys      []string
+}
+
+//Error returns error message, like `comfyconf: short name "host" is ambiguous: cache.host, db.host`
+func (e *AmbiguousNameError) Error() string {
+	return fmt.Sprintf("comfyconf: short name %q is ambiguous: %s", e.ShortName, strings.Join(e.Keys, ", "))
+}
+
+//StrictErrors all errors, that were reported by strict middlewares during Parse
+type StrictErrors []error
+
+//Error returns messages of all errors
+func (e StrictErrors) Error() string {
+	messages := make([]string, 0, len(e))
+
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+//StrictMiddleware is optional interface for middlewares, that report values, which could not be converted
+//to option type or could not be resolved, instead of silently ignoring them
+type StrictMiddleware interface {
+	//StrictErrors returns errors, that were found since latest Init
+	StrictErrors() []error
+}
+
+//SetStrict enables strict mode. In strict mode Parse fails with StrictErrors, when configuration has value of option,
+//that can not be converted to option type, or when option short name matches several keys, instead of falling back
+//to value of other middleware or default
+func (j *JSON) SetStrict(strict bool) {
+	j.strict = strict
+}
+
+//StrictErrors returns errors, that were found since latest Init, when strict mode is enabled
+func (j *JSON) StrictErrors() []error {
+	return j.strictErrors
+}
+
+//reportType records type error of value with flattened key in strict mode
+func (j *JSON) reportType(key string, expected OptionType, value interface{}) {
+	if !j.strict {
+		return
+	}
+
+	for _, existing := range j.strictErrors {
+		if typeErr, isOk := existing.(*TypeError); isOk && typeErr.Key == key {
+			return
+		}
+	}
+
+	file, line, column := j.locate(keyPointer(key), j.origins)
+
+	j.strictErrors = append(j.strictErrors, &TypeError{
+		Key:      key,
+		File:     file,
+		Line:     line,
+		Column:   column,
+		Expected: expected.String(),
+		Actual:   jsonTypeName(value),
+	})
+}
+
+//reportAmbiguous records ambiguous short name in strict mode
+func (j *JSON) reportAmbiguous(shortName string) {
+	if !j.strict {
+		return
+	}
+
+	for _, existing := range j.strictErrors {
+		if ambiguousErr, isOk := existing.(*AmbiguousNameError); isOk && ambiguousErr.ShortName == shortName {
+			return
+		}
+	}
+
+	j.strictErrors = append(j.strictErrors, &AmbiguousNameError{shortName, j.collisions[shortName]})
+}
+
+//resetSources clears sources of files, that were read during previous Init
+func (j *JSON) resetSources() {
+	j.sources = make(map[fileID]*jsonSource)
+	j.positions = make(map[fileID]map[string]int)
+	j.strictErrors = nil
+	j.filesystems = nil
+}
+
+//locate returns file, line and column of value with JSON Pointer. File is taken from origins of flattened key
+//or middleware file is used
+func (j *JSON) locate(pointer string, origins map[string]valueOrigin) (string, int, int) {
+	origin := origins[pointerKey(pointer)]
+	file := origin.file
+
+	//values of profile section are located inside of section, like `/profiles/prod`
+	if len(origin.section) != 0 {
+		pointer = keyPointer(origin.section) + pointer
+	}
+
+	if len(file.path) == 0 {
+		file = j.rootFile()
+	}
+
+	source, isOk := j.sources[file]
+
+	if !isOk || len(source.content) == 0 || source.converted {
+		return file.path, 0, 0
+	}
+
+	positions, isOk := j.positions[file]
+
+	if !isOk {
+		positions = jsonPositions(source.decoded)
+		j.positions[file] = positions
+	}
+
+	offset, isOk := positions[pointer]
+
+	if !isOk {
+		return file.path, 0, 0
+	}
+
+	line, column := lineColumn(source.content, source.sourceOffset(offset))
+
+	return file.path, line, column
+}
+
+//newJSONError converts decoding error to JSONError with position in source and snippet, if error has offset.
+//Errors of converted sources have no position
+func newJSONError(path string, source *jsonSource, err error) error {
+	var offset int
+
+	switch err.(type) {
+	case *relaxedSyntaxError, *json.SyntaxError, *json.UnmarshalTypeError:
+		if source.converted {
+			return fmt.Errorf("comfyconf: %s: %v", path, err)
+		}
+	}
+
+	switch e := err.(type) {
+	case *relaxedSyntaxError:
+		offset = e.offset
+	case *json.SyntaxError:
+		offset = source.sourceOffset(decodedErrorOffset(source.decoded, int(e.Offset), err))
+	case *json.UnmarshalTypeError:
+		offset = source.sourceOffset(decodedErrorOffset(source.decoded, int(e.Offset), err))
+	default:
+		if len(path) == 0 {
+			return err
+		}
+
+		return fmt.Errorf("comfyconf: %s: %v", path, err)
+	}
+
+	line, column := lineColumn(source.content, offset)
+
+	return &JSONError{
+		File:    path,
+		Line:    line,
+		Column:  column,
+		Snippet: snippet(source.content, line, column),
+		Message: err.Error(),
+	}
+}
+
+//decodedErrorOffset returns offset of invalid character, decoder offset points after it
+func decodedErrorOffset(content []byte, offset int, err error) int {
+	if offset > 0 && offset <= len(content) && !(offset == len(content) && isEOFError(err)) {
+		offset--
+	}
+
+	return offset
+}
+
+func isEOFError(err error) bool {
+	return strings.Contains(err.Error(), "unexpected end of JSON input")
+}
+
+//snippet returns source line with marker under column, like `  3 | "port": ,` and `    |         ^`
+func snippet(content []byte, line int, column int) string {
+	lines := bytes.Split(content, []byte("\n"))
+
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	source := strings.TrimRight(string(lines[line-1]), "\r")
+	number := fmt.Sprintf("%4d | ", line)
+	marker := strings.Repeat(" ", len(number)-2) + "| "
+
+	for i := 0; i < column-1 && i < len(source); i++ {
+		if source[i] == '\t' {
+			marker += "\t"
+		} else {
+			marker += " "
+		}
+	}
+
+	return number + source + "\n" + marker + "^"
+}
+
+//position returns position in file, like `config.json:3:5` or `line 3, column 5`
+func position(file string, line int, column int) string {
+	if len(file) == 0 {
+		return fmt.Sprintf("line %d, column %d", line, column)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", file, line, column)
+}
@@ -0,0 +1,114 @@
+package comfyconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSON_Init_JSONError(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json": "{\n  \"db\": {\n    \"port\": ,\n  }\n}",
+		"eof.json":    "{\n  \"db\": {",
+		"array.json":  "[1, 2]",
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	err := NewJSON(path).Init()
+
+	assert.IsType(t, &JSONError{}, err)
+
+	jsonErr := err.(*JSONError)
+	assert.Equal(t, path, jsonErr.File)
+	assert.Equal(t, 3, jsonErr.Line)
+	assert.Equal(t, 13, jsonErr.Column)
+	assert.Equal(t, "comfyconf: "+path+":3:13: invalid character ',' looking for beginning of value\n"+
+		"   3 |     \"port\": ,\n"+
+		"     |             ^", err.Error())
+
+	err = NewJSON(filepath.Join(dir, "eof.json")).Init()
+	assert.IsType(t, &JSONError{}, err)
+	assert.Equal(t, 2, err.(*JSONError).Line)
+	assert.Equal(t, 10, err.(*JSONError).Column)
+
+	err = NewJSON(filepath.Join(dir, "array.json")).Init()
+	assert.IsType(t, &JSONError{}, err)
+	assert.Equal(t, 1, err.(*JSONError).Line)
+
+	err = NewJSONWithCustomReader(func(j *JSON) ([]byte, error) {
+		return []byte(`{"a": }`), nil
+	}).Init()
+	assert.Equal(t, "comfyconf: line 1, column 7: invalid character '}' looking for beginning of value\n"+
+		"   1 | {\"a\": }\n"+
+		"     |       ^", err.Error())
+}
+
+func TestJSON_SetStrict(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json": "{\n  \"db\": {\"port\": \"5432\", \"timeout\": 1.5},\n  \"debug\": \"yes\",\n  \"tags\": \"a,b\",\n  \"name\": \"app\"\n}",
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+
+	newConf := func(strict bool) *Conf {
+		j := NewJSON(path)
+		j.SetStrict(strict)
+
+		conf := New(j)
+		conf.Int("p", "db.port", 80, "Port")
+		conf.Int("", "db.timeout", 1, "Timeout")
+		conf.Bool("", "debug", false, "Debug")
+		conf.Slice("", "tags", nil, "Tags")
+		conf.String("", "name", "", "Name")
+
+		return conf
+	}
+
+	conf := newConf(false)
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, 80, conf.Lookup("db.port").GetValue())
+	assert.Equal(t, 1, conf.Lookup("db.timeout").GetValue())
+
+	err := newConf(true).Parse()
+	assert.IsType(t, StrictErrors{}, err)
+
+	typeErrors := err.(StrictErrors)
+	assert.Len(t, typeErrors, 4)
+
+	assert.Equal(t, &TypeError{Key: "db.port", File: path, Line: 2, Column: 18, Expected: "int", Actual: "string"}, typeErrors[0])
+	assert.Equal(t, &TypeError{Key: "db.timeout", File: path, Line: 2, Column: 37, Expected: "int", Actual: "number"}, typeErrors[1])
+	assert.Equal(t, &TypeError{Key: "debug", File: path, Line: 3, Column: 12, Expected: "bool", Actual: "string"}, typeErrors[2])
+	assert.Equal(t, &TypeError{Key: "tags", File: path, Line: 4, Column: 11, Expected: "slice", Actual: "string"}, typeErrors[3])
+
+	assert.Equal(t, "comfyconf: "+path+":2:18: db.port: expected int, got string", typeErrors[0].Error())
+	assert.Equal(t, "comfyconf: name: expected int, got string", (&TypeError{Key: "name", Expected: "int", Actual: "string"}).Error())
+}
+
+func TestJSON_Strict_EscapedPointer(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json": "{\n  \"io/rate\": \"fast\",\n  \"a~b\": \"yes\"\n}",
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+
+	j := NewJSON(path)
+	j.SetStrict(true)
+
+	conf := New(j)
+	conf.Int("", "io/rate", 1, "Rate")
+	conf.Bool("", "a~b", false, "Flag")
+
+	err := conf.Parse()
+	assert.IsType(t, StrictErrors{}, err)
+
+	typeErrors := err.(StrictErrors)
+	assert.Len(t, typeErrors, 2)
+
+	assert.Contains(t, typeErrors, &TypeError{Key: "io/rate", File: path, Line: 2, Column: 14, Expected: "int", Actual: "string"})
+	assert.Contains(t, typeErrors, &TypeError{Key: "a~b", File: path, Line: 3, Column: 10, Expected: "bool", Actual: "string"})
+}
@@ -0,0 +1,265 @@
+package comfyconf
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//FileLayer single file or glob pattern of layered configuration
+type FileLayer struct {
+	//Pattern file path or glob pattern, `~/` is replaced by user home directory
+	Pattern string
+	//Optional layer is skipped, when file does not exist or pattern does not match any file
+	Optional bool
+	//FS filesystem, from where files are read, like embed.FS. Operating system files are read, when it is nil
+	FS fs.FS
+}
+
+//RequiredFile returns layer, that must exist
+func RequiredFile(pattern string) FileLayer {
+	return FileLayer{Pattern: pattern}
+}
+
+//OptionalFile returns layer, that is skipped when file does not exist
+func OptionalFile(pattern string) FileLayer {
+	return FileLayer{Pattern: pattern, Optional: true}
+}
+
+//NewJSONFiles returns pointer to instance of layered JSON configuration middleware.
+//Files are deep merged in provided order, so values from latest files have higher priority
+func NewJSONFiles(layers ...FileLayer) *JSONFiles {
+	return &JSONFiles{
+		JSON: JSON{
+			reader: DefaultJSONReader,
+		},
+		layers:       layers,
+		appendSlices: make(map[string]bool),
+	}
+}
+
+//JSONFiles structure implements Middleware instance for layered JSON configuration, like
+//`/etc/app/config.json`, `/etc/app/conf.d/*.json` and `~/.config/app.json`
+type JSONFiles struct {
+	JSON
+	layers       []FileLayer
+	appendSlices map[string]bool
+	files        []fileID
+}
+
+//Name returns name of layered JSON middleware source
+func (f *JSONFiles) Name() string {
+	return "json"
+}
+
+//SetSliceAppend sets full names of slices, that are appended by later files instead of being replaced
+func (f *JSONFiles) SetSliceAppend(fullNames ...string) {
+	for _, name := range fullNames {
+		f.appendSlices[name] = true
+	}
+}
+
+//Files returns list of files, that were read during latest Init, in merge order
+func (f *JSONFiles) Files() []string {
+	paths := make([]string, len(f.files))
+
+	for i, file := range f.files {
+		paths[i] = file.path
+	}
+
+	return paths
+}
+
+//Init reads and merges all layers
+func (f *JSONFiles) Init() error {
+	merged := make(map[string]interface{})
+	origins := make(map[string]valueOrigin)
+
+	f.files = make([]fileID, 0)
+	f.resetSources()
+
+	for _, layer := range f.layers {
+		paths, err := layer.resolve()
+
+		if err != nil {
+			return err
+		}
+
+		if layer.FS != nil {
+			f.filesystems = append(f.filesystems, layer.FS)
+		}
+
+		for _, path := range paths {
+			file := fileID{path: path}
+
+			if layer.FS != nil {
+				file = fsFile(path, len(f.filesystems)-1)
+			}
+
+			content, err := f.readFile(file)
+
+			if err != nil {
+				if layer.Optional && os.IsNotExist(err) {
+					continue
+				}
+
+				return fmt.Errorf("comfyconf: %v", err)
+			}
+
+			tmpParsed, fileOrigins, err := f.decode(file, content, []fileID{file})
+
+			if err != nil {
+				return err
+			}
+
+			mergeJSON(merged, tmpParsed, "", f.appendSlices)
+
+			for key, origin := range fileOrigins {
+				origins[key] = origin
+			}
+
+			f.files = append(f.files, file)
+		}
+	}
+
+	if err := f.validateSchema(merged, origins); err != nil {
+		return err
+	}
+
+	f.tree = merged
+	f.parsed = f.parse(merged)
+	f.origins = origins
+
+	f.prepareIndex()
+
+	return nil
+}
+
+//resolve returns files of layer
+func (l FileLayer) resolve() ([]string, error) {
+	pattern := l.Pattern
+
+	if l.FS != nil {
+		return l.resolveFS()
+	}
+
+	if strings.HasPrefix(pattern, "~/") {
+		home, err := os.UserHomeDir()
+
+		if err != nil {
+			if l.Optional {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("comfyconf: %s: %v", pattern, err)
+		}
+
+		pattern = filepath.Join(home, pattern[2:])
+	}
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}, nil
+	}
+
+	paths, err := filepath.Glob(pattern)
+
+	if err != nil {
+		return nil, fmt.Errorf("comfyconf: %s: %v", pattern, err)
+	}
+
+	paths = withoutProfileFiles(paths)
+
+	if len(paths) == 0 && !l.Optional {
+		return nil, fmt.Errorf("comfyconf: %s: no files match pattern", pattern)
+	}
+
+	return paths, nil
+}
+
+//resolveFS returns files of layer of filesystem
+func (l FileLayer) resolveFS() ([]string, error) {
+	pattern := fsPath(l.Pattern)
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}, nil
+	}
+
+	paths, err := fs.Glob(l.FS, pattern)
+
+	if err != nil {
+		return nil, fmt.Errorf("comfyconf: %s: %v", pattern, err)
+	}
+
+	paths = withoutProfileFiles(paths)
+
+	if len(paths) == 0 && !l.Optional {
+		return nil, fmt.Errorf("comfyconf: %s: no files match pattern", pattern)
+	}
+
+	return paths, nil
+}
+
+//withoutProfileFiles removes profile overlays from files matched by glob pattern. File is overlay, when it has
+//sibling without profile part, so `cache.prod.json` is skipped, if `cache.json` is matched too.
+//Overlays are applied by SetProfile after base files
+func withoutProfileFiles(paths []string) []string {
+	matched := make(map[string]bool, len(paths))
+
+	for _, path := range paths {
+		matched[path] = true
+	}
+
+	result := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		ext := filepath.Ext(path)
+		base := strings.TrimSuffix(path, ext)
+		profileExt := filepath.Ext(base)
+
+		if len(profileExt) > 1 && matched[strings.TrimSuffix(base, profileExt)+ext] {
+			continue
+		}
+
+		result = append(result, path)
+	}
+
+	return result
+}
+
+//mergeJSON deep merges src object into dst object. Nested objects are merged, slices with full names from
+//appendSlices are appended and all other values are replaced
+func mergeJSON(dst map[string]interface{}, src map[string]interface{}, prefix string, appendSlices map[string]bool) {
+	for k, v := range src {
+		key := k
+
+		if len(prefix) != 0 {
+			key = prefix + "." + k
+		}
+
+		srcMap, isSrcMap := v.(map[string]interface{})
+		dstMap, isDstMap := dst[k].(map[string]interface{})
+
+		if isSrcMap && isDstMap {
+			mergeJSON(dstMap, srcMap, key, appendSlices)
+			continue
+		}
+
+		srcSlice, isSrcSlice := v.([]interface{})
+		dstSlice, isDstSlice := dst[k].([]interface{})
+
+		if isSrcSlice && isDstSlice && appendSlices[key] {
+			dst[k] = append(append(make([]interface{}, 0, len(dstSlice)+len(srcSlice)), dstSlice...), srcSlice...)
+			continue
+		}
+
+		if isSrcMap {
+			copied := make(map[string]interface{})
+			mergeJSON(copied, srcMap, key, appendSlices)
+			v = copied
+		}
+
+		dst[k] = v
+	}
+}
@@ -0,0 +1,106 @@
+package comfyconf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func prepareJSONFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "comfyconf")
+	assert.NoError(t, err)
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	return dir
+}
+
+func TestJSONFiles_Init(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json":          `{"db": {"host": "localhost", "port": 5432}, "plugins": ["a"], "tags": ["x"]}`,
+		"conf.d/10-db.json":    `{"db": {"host": "db.local"}, "plugins": ["b"], "tags": ["y"]}`,
+		"conf.d/20-extra.json": `{"plugins": ["c"], "debug": true}`,
+		"conf.d/ignored.txt":   `{"debug": false}`,
+	})
+	defer os.RemoveAll(dir)
+
+	f := NewJSONFiles(
+		RequiredFile(filepath.Join(dir, "config.json")),
+		OptionalFile(filepath.Join(dir, "conf.d", "*.json")),
+		OptionalFile(filepath.Join(dir, "missing.json")),
+	)
+	f.SetSliceAppend("plugins")
+
+	assert.NoError(t, f.Init())
+
+	assert.Equal(t, []string{
+		filepath.Join(dir, "config.json"),
+		filepath.Join(dir, "conf.d", "10-db.json"),
+		filepath.Join(dir, "conf.d", "20-extra.json"),
+	}, f.Files())
+
+	host, isOk := f.ParseString("host", "db.host")
+	assert.True(t, isOk)
+	assert.Equal(t, "db.local", host)
+
+	port, isOk := f.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 5432, port)
+
+	plugins, isOk := f.ParseSlice("plugins", "plugins")
+	assert.True(t, isOk)
+	assert.Equal(t, []interface{}{"a", "b", "c"}, plugins)
+
+	tags, isOk := f.ParseSlice("tags", "tags")
+	assert.True(t, isOk)
+	assert.Equal(t, []interface{}{"y"}, tags)
+
+	origin, isOk := f.Origin("host", "db.host")
+	assert.True(t, isOk)
+	assert.Equal(t, "json:"+filepath.Join(dir, "conf.d", "10-db.json"), origin)
+
+	origin, isOk = f.Origin("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, "json:"+filepath.Join(dir, "config.json"), origin)
+}
+
+func TestJSONFiles_Conf_Provenance(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"base.json":     `{"level": "info", "port": 80}`,
+		"override.json": `{"level": "debug"}`,
+	})
+	defer os.RemoveAll(dir)
+
+	conf := New(NewJSONFiles(RequiredFile(filepath.Join(dir, "base.json")), RequiredFile(filepath.Join(dir, "override.json"))))
+
+	level := conf.String("l", "level", "", "Log level")
+	conf.Int("p", "port", 0, "Port")
+
+	assert.NoError(t, conf.Parse())
+
+	assert.Equal(t, "debug", *level)
+	assert.Equal(t, "json:"+filepath.Join(dir, "override.json"), conf.Lookup("level").GetSource())
+	assert.Equal(t, "json:"+filepath.Join(dir, "base.json"), conf.Lookup("port").GetSource())
+}
+
+func TestJSONFiles_Init_Errors(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"broken.json": `{`,
+	})
+	defer os.RemoveAll(dir)
+
+	assert.Error(t, NewJSONFiles(RequiredFile(filepath.Join(dir, "missing.json"))).Init())
+	assert.Error(t, NewJSONFiles(RequiredFile(filepath.Join(dir, "*.yaml"))).Init())
+	assert.NoError(t, NewJSONFiles(OptionalFile(filepath.Join(dir, "*.yaml"))).Init())
+
+	err := NewJSONFiles(OptionalFile(filepath.Join(dir, "broken.json"))).Init()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "broken.json")
+}
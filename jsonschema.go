@@ -0,0 +1,412 @@
+package comfyconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//SchemaError single violation of JSON Schema. Pointer is JSON Pointer of invalid value,
+//file, line and column are set when value was found in configuration file
+type SchemaError struct {
+	Pointer string
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+//Error returns error message, like `comfyconf: config.json:3:13: /db/port: expected integer`
+func (e *SchemaError) Error() string {
+	pointer := e.Pointer
+
+	if len(pointer) == 0 {
+		pointer = "/"
+	}
+
+	if len(e.File) != 0 && e.Line != 0 {
+		return fmt.Sprintf("comfyconf: %s:%d:%d: %s: %s", e.File, e.Line, e.Column, pointer, e.Message)
+	}
+
+	return fmt.Sprintf("comfyconf: %s: %s", pointer, e.Message)
+}
+
+//SchemaErrors all violations of JSON Schema, that were found in configuration
+type SchemaErrors []*SchemaError
+
+//Error returns messages of all violations
+func (e SchemaErrors) Error() string {
+	messages := make([]string, 0, len(e))
+
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+//SetSchema sets JSON Schema, that configuration is validated against during Init, before it is flattened.
+//Supported keywords are type, enum, const, properties, required, additionalProperties, items, minimum, maximum,
+//exclusiveMinimum, exclusiveMaximum, minLength, maxLength, pattern, minItems and maxItems.
+//Init returns SchemaErrors, when configuration does not match schema
+func (j *JSON) SetSchema(schema []byte) error {
+	parsed := make(map[string]interface{})
+
+	if err := json.Unmarshal(schema, &parsed); err != nil {
+		return fmt.Errorf("comfyconf: invalid schema: %v", err)
+	}
+
+	j.schema = parsed
+
+	return nil
+}
+
+//validateSchema validates decoded configuration and resolves positions of violations in files
+func (j *JSON) validateSchema(data map[string]interface{}, origins map[string]valueOrigin) error {
+	if j.schema == nil {
+		return nil
+	}
+
+	violations := make(SchemaErrors, 0)
+	validateSchemaValue(j.schema, data, "", false, &violations)
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	for _, violation := range violations {
+		if file, line, column := j.locate(violation.Pointer, origins); line != 0 {
+			violation.File, violation.Line, violation.Column = file, line, column
+		}
+	}
+
+	return violations
+}
+
+//validateSchemaValue validates value against schema and collects violations.
+//Values of `writeOnly` properties, like secrets, are not shown in messages
+func validateSchemaValue(schema map[string]interface{}, value interface{}, pointer string, isSecret bool, violations *SchemaErrors) {
+	fail := func(format string, args ...interface{}) {
+		*violations = append(*violations, &SchemaError{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+	}
+
+	isSecret = isSecret || schema["writeOnly"] == true
+
+	show := func(text string) string {
+		if isSecret {
+			return Redacted
+		}
+
+		return text
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) != 0 {
+		matched := false
+
+		for _, t := range types {
+			if isSchemaType(t, value) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			fail("expected %s, got %s", strings.Join(types, " or "), jsonTypeName(value))
+			return
+		}
+	}
+
+	if enum, isOk := schema["enum"].([]interface{}); isOk {
+		matched := false
+
+		for _, allowed := range enum {
+			if jsonEqual(allowed, value) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			fail("value %s is not one of %s", show(jsonString(value)), jsonString(enum))
+		}
+	}
+
+	if expected, isExist := schema["const"]; isExist && !jsonEqual(expected, value) {
+		fail("expected %s", jsonString(expected))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		validateSchemaObject(schema, v, pointer, isSecret, violations)
+	case []interface{}:
+		if min, isOk := schemaNumber(schema, "minItems"); isOk && float64(len(v)) < min {
+			fail("expected at least %v items", min)
+		}
+
+		if max, isOk := schemaNumber(schema, "maxItems"); isOk && float64(len(v)) > max {
+			fail("expected at most %v items", max)
+		}
+
+		if items, isOk := schema["items"].(map[string]interface{}); isOk {
+			for i, item := range v {
+				validateSchemaValue(items, item, pointer+"/"+strconv.Itoa(i), isSecret, violations)
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+
+		if min, isOk := schemaNumber(schema, "minLength"); isOk && length < min {
+			fail("expected at least %v characters", min)
+		}
+
+		if max, isOk := schemaNumber(schema, "maxLength"); isOk && length > max {
+			fail("expected at most %v characters", max)
+		}
+
+		if pattern, isOk := schema["pattern"].(string); isOk {
+			if expr, err := regexp.Compile(pattern); err == nil && !expr.MatchString(v) {
+				fail("value %s does not match pattern %q", show(strconv.Quote(v)), pattern)
+			}
+		}
+	case float64:
+		if min, isOk := schemaNumber(schema, "minimum"); isOk && v < min {
+			fail("value %s is less than %v", show(jsonString(v)), min)
+		}
+
+		if max, isOk := schemaNumber(schema, "maximum"); isOk && v > max {
+			fail("value %s is greater than %v", show(jsonString(v)), max)
+		}
+
+		if min, isOk := schemaNumber(schema, "exclusiveMinimum"); isOk && v <= min {
+			fail("value %s must be greater than %v", show(jsonString(v)), min)
+		}
+
+		if max, isOk := schemaNumber(schema, "exclusiveMaximum"); isOk && v >= max {
+			fail("value %s must be less than %v", show(jsonString(v)), max)
+		}
+	}
+}
+
+func validateSchemaObject(schema map[string]interface{}, value map[string]interface{}, pointer string, isSecret bool, violations *SchemaErrors) {
+	if required, isOk := schema["required"].([]interface{}); isOk {
+		for _, name := range required {
+			if name, isOk := name.(string); isOk {
+				if _, isExist := value[name]; !isExist {
+					*violations = append(*violations, &SchemaError{Pointer: pointer, Message: fmt.Sprintf("missing required property %q", name)})
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(value))
+
+	for key := range value {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPointer := pointer + "/" + escapePointer(key)
+
+		if property, isOk := properties[key].(map[string]interface{}); isOk {
+			validateSchemaValue(property, value[key], childPointer, isSecret, violations)
+			continue
+		}
+
+		if _, isExist := properties[key]; isExist {
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*violations = append(*violations, &SchemaError{Pointer: childPointer, Message: "unknown property"})
+			}
+		case map[string]interface{}:
+			validateSchemaValue(additional, value[key], childPointer, isSecret, violations)
+		}
+	}
+}
+
+func schemaTypes(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		types := make([]string, 0, len(v))
+
+		for _, t := range v {
+			if t, isOk := t.(string); isOk {
+				types = append(types, t)
+			}
+		}
+
+		return types
+	}
+
+	return nil
+}
+
+func isSchemaType(t string, value interface{}) bool {
+	switch t {
+	case "integer":
+		v, isOk := value.(float64)
+		return isOk && v == math.Trunc(v)
+	case "number":
+		_, isOk := value.(float64)
+		return isOk
+	}
+
+	return jsonTypeName(value) == t
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+
+	return fmt.Sprintf("%T", value)
+}
+
+func schemaNumber(schema map[string]interface{}, keyword string) (float64, bool) {
+	v, isOk := schema[keyword].(float64)
+	return v, isOk
+}
+
+func jsonEqual(a interface{}, b interface{}) bool {
+	return jsonString(a) == jsonString(b)
+}
+
+func jsonString(value interface{}) string {
+	content, _ := json.Marshal(value)
+	return string(content)
+}
+
+//escapePointer escapes reference token of JSON Pointer
+func escapePointer(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+//unescapePointer unescapes reference token of JSON Pointer
+func unescapePointer(token string) string {
+	return strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+}
+
+//pointerKey returns flattened key of JSON Pointer, like `db.port` for `/db/port`
+func pointerKey(pointer string) string {
+	if len(pointer) == 0 {
+		return ""
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+
+	for i, token := range tokens {
+		tokens[i] = unescapePointer(token)
+	}
+
+	return strings.Join(tokens, ".")
+}
+
+//keyPointer returns JSON Pointer of flattened key, like `/db/port` for `db.port`
+func keyPointer(key string) string {
+	pointer := ""
+
+	for _, token := range strings.Split(key, ".") {
+		pointer += "/" + escapePointer(token)
+	}
+
+	return pointer
+}
+
+//jsonPositions returns offsets of all values in JSON content by JSON Pointer
+func jsonPositions(content []byte) map[string]int {
+	positions := make(map[string]int)
+	decoder := json.NewDecoder(bytes.NewReader(content))
+
+	var walk func(pointer string) error
+
+	walk = func(pointer string) error {
+		positions[pointer] = skipJSONSeparators(content, int(decoder.InputOffset()))
+
+		token, err := decoder.Token()
+
+		if err != nil {
+			return err
+		}
+
+		switch token {
+		case json.Delim('{'):
+			for decoder.More() {
+				key, err := decoder.Token()
+
+				if err != nil {
+					return err
+				}
+
+				if err := walk(pointer + "/" + escapePointer(fmt.Sprint(key))); err != nil {
+					return err
+				}
+			}
+
+			_, err = decoder.Token()
+		case json.Delim('['):
+			for i := 0; decoder.More(); i++ {
+				if err := walk(pointer + "/" + strconv.Itoa(i)); err != nil {
+					return err
+				}
+			}
+
+			_, err = decoder.Token()
+		}
+
+		return err
+	}
+
+	_ = walk("")
+
+	return positions
+}
+
+//skipJSONSeparators returns offset of next token after whitespaces, colons and commas
+func skipJSONSeparators(content []byte, offset int) int {
+	for offset < len(content) {
+		switch content[offset] {
+		case ' ', '\t', '\r', '\n', ':', ',':
+			offset++
+		default:
+			return offset
+		}
+	}
+
+	return offset
+}
+
+//lineColumn returns line and column of offset, both starting from 1
+func lineColumn(content []byte, offset int) (int, int) {
+	if offset > len(content) {
+		offset = len(content)
+	}
+
+	line := 1 + bytes.Count(content[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(content[:offset], '\n')
+
+	return line, column
+}
@@ -0,0 +1,139 @@
+package comfyconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["db"],
+	"additionalProperties": false,
+	"properties": {
+		"db": {
+			"type": "object",
+			"required": ["host"],
+			"properties": {
+				"host": {"type": "string", "minLength": 1},
+				"port": {"type": "integer", "minimum": 1, "maximum": 65535}
+			}
+		},
+		"level": {"enum": ["debug", "info"]},
+		"tags": {"type": "array", "maxItems": 2, "items": {"type": "string", "pattern": "^[a-z]+$"}}
+	}
+}`
+
+func TestJSON_SetSchema(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json": "{\n  \"db\": {\n    \"port\": \"5432\"\n  },\n  \"level\": \"trace\",\n  \"tags\": [\"a\", \"B\", \"c\"],\n  \"extra\": 1\n}",
+		"valid.json":  `{"db": {"host": "localhost", "port": 5432}, "level": "info", "tags": ["a"]}`,
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+
+	j := NewJSON(path)
+	assert.NoError(t, j.SetSchema([]byte(testSchema)))
+
+	err := j.Init()
+	assert.IsType(t, SchemaErrors{}, err)
+
+	violations := err.(SchemaErrors)
+	assert.Len(t, violations, 6)
+
+	assert.Equal(t, &SchemaError{Pointer: "/db", File: path, Line: 2, Column: 9, Message: `missing required property "host"`}, violations[0])
+	assert.Equal(t, &SchemaError{Pointer: "/db/port", File: path, Line: 3, Column: 13, Message: "expected integer, got string"}, violations[1])
+	assert.Equal(t, &SchemaError{Pointer: "/extra", File: path, Line: 7, Column: 12, Message: "unknown property"}, violations[2])
+	assert.Equal(t, &SchemaError{Pointer: "/level", File: path, Line: 5, Column: 12, Message: `value "trace" is not one of ["debug","info"]`}, violations[3])
+	assert.Equal(t, &SchemaError{Pointer: "/tags", File: path, Line: 6, Column: 11, Message: "expected at most 2 items"}, violations[4])
+	assert.Equal(t, &SchemaError{Pointer: "/tags/1", File: path, Line: 6, Column: 17, Message: `value "B" does not match pattern "^[a-z]+$"`}, violations[5])
+
+	assert.Equal(t, "comfyconf: "+path+":3:13: /db/port: expected integer, got string", violations[1].Error())
+
+	j = NewJSON(filepath.Join(dir, "valid.json"))
+	assert.NoError(t, j.SetSchema([]byte(testSchema)))
+	assert.NoError(t, j.Init())
+
+	port, isOk := j.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 5432, port)
+
+	assert.Error(t, j.SetSchema([]byte(`{`)))
+}
+
+func TestJSON_SetSchema_Include(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json": `{"$include": "db.json", "level": "info"}`,
+		"db.json":     "{\n\"db\": {\"host\": \"localhost\", \"port\": 0}}",
+	})
+	defer os.RemoveAll(dir)
+
+	j := NewJSON(filepath.Join(dir, "config.json"))
+	assert.NoError(t, j.SetSchema([]byte(testSchema)))
+
+	err := j.Init()
+	assert.EqualError(t, err, "comfyconf: "+filepath.Join(dir, "db.json")+":2:37: /db/port: value 0 is less than 1")
+}
+
+func TestJSONFiles_SetSchema(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"base.json":     `{"db": {"host": "localhost"}}`,
+		"override.json": `{"db": {"port": 1.5}}`,
+	})
+	defer os.RemoveAll(dir)
+
+	f := NewJSONFiles(RequiredFile(filepath.Join(dir, "base.json")), RequiredFile(filepath.Join(dir, "override.json")))
+	assert.NoError(t, f.SetSchema([]byte(testSchema)))
+
+	assert.EqualError(t, f.Init(), "comfyconf: "+filepath.Join(dir, "override.json")+":1:17: /db/port: expected integer, got number")
+}
+
+func TestJSON_SetSchema_Profile(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json":    "{\n  \"db\": {\"port\": 5432},\n  \"profiles\": {\n    \"prod\": {\"db\": {\"port\": 70000}}\n  }\n}",
+		"config.ci.json": `{"token": "ABC"}`,
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+
+	j := NewJSON(path)
+	assert.NoError(t, j.SetSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"db": {"type": "object", "properties": {"port": {"type": "integer", "maximum": 65535}}},
+			"token": {"type": "string", "writeOnly": true, "pattern": "^[a-z]+$"},
+			"profiles": {"type": "object"}
+		}
+	}`)))
+
+	assert.NoError(t, j.Init())
+	assert.Equal(t, SchemaErrors{
+		{Pointer: "/db/port", File: path, Line: 4, Column: 29, Message: "value 70000 is greater than 65535"},
+	}, j.SetProfile("prod"))
+
+	assert.NoError(t, j.Init())
+	assert.Equal(t, SchemaErrors{
+		{Pointer: "/token", File: filepath.Join(dir, "config.ci.json"), Line: 1, Column: 11, Message: `value ****** does not match pattern "^[a-z]+$"`},
+	}, j.SetProfile("ci"))
+
+	assert.NoError(t, j.Init())
+	assert.NoError(t, j.SetProfile("dev"))
+}
+
+func TestJSONPositions(t *testing.T) {
+	content := []byte("{\"a\": [1, {\"b/c\": true}], \"d\" : null}")
+	positions := jsonPositions(content)
+
+	assert.Equal(t, 0, positions[""])
+	assert.Equal(t, 6, positions["/a"])
+	assert.Equal(t, 7, positions["/a/0"])
+	assert.Equal(t, 10, positions["/a/1"])
+	assert.Equal(t, 18, positions["/a/1/b~1c"])
+	assert.Equal(t, 32, positions["/d"])
+
+	assert.Equal(t, "a.b/c", pointerKey("/a/b~1c"))
+}
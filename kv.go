@@ -0,0 +1,242 @@
+package comfyconf
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//DefaultKVWaitTime longest time of single long-polling request of KV middleware
+const DefaultKVWaitTime = 5 * time.Minute
+
+//KVPair key and value of key-value store
+type KVPair struct {
+	Key   string
+	Value []byte
+}
+
+//KVClient is interface of key-value store client, that is used by KV middleware.
+//Index is store specific version of data, like Consul index or etcd revision
+type KVClient interface {
+	//List returns all pairs with keys starting with prefix and current index of store
+	List(prefix string) ([]KVPair, uint64, error)
+	//Wait blocks until keys starting with prefix are changed after index, until wait time is elapsed or until
+	//context is canceled. Returns current index of store, that equals to provided index, if nothing was changed
+	Wait(ctx context.Context, prefix string, index uint64, waitTime time.Duration) (uint64, error)
+}
+
+//NewKV creates middleware, that reads configuration from keys with prefix of key-value store.
+//Keys are mapped to dotted full names, so `app/db/port` with prefix `app/` is `db.port`
+func NewKV(client KVClient, prefix string) *KV {
+	return &KV{
+		Flags: Flags{
+			parsed:      make(map[string]string),
+			parsedSlice: make(map[string][]interface{}),
+		},
+		client:   client,
+		prefix:   prefix,
+		waitTime: DefaultKVWaitTime,
+	}
+}
+
+//KV structure that implements middleware interface for key-value stores, like Consul or etcd.
+//Values are used as strings, slices are read line by line
+type KV struct {
+	Flags
+	client   KVClient
+	prefix   string
+	waitTime time.Duration
+
+	//mu guards index, that is read by watching goroutine while Reload initializes middleware again
+	mu    sync.Mutex
+	index uint64
+}
+
+//SetWaitTime sets longest time of single long-polling request, that is made by Changed
+func (k *KV) SetWaitTime(waitTime time.Duration) {
+	k.waitTime = waitTime
+}
+
+//Name returns name of KV middleware source
+func (k *KV) Name() string {
+	return "kv:" + k.prefix
+}
+
+//Init reads all keys with prefix. Init can be called again for reloading changed keys
+func (k *KV) Init() error {
+	pairs, index, err := k.client.List(k.prefix)
+
+	if err != nil {
+		return fmt.Errorf("comfyconf: kv %q: %v", k.prefix, err)
+	}
+
+	k.parsed = make(map[string]string)
+	k.parsedSlice = make(map[string][]interface{})
+
+	k.mu.Lock()
+	k.index = index
+	k.mu.Unlock()
+
+	for _, pair := range pairs {
+		key, isOk := k.optionName(pair.Key)
+
+		if !isOk {
+			continue
+		}
+
+		value := strings.TrimRight(string(pair.Value), "\r\n")
+
+		k.parsed[key] = value
+		k.parsedSlice[key] = splitLines(value)
+	}
+
+	return nil
+}
+
+//Changed waits for change of keys with long-polling request of store client
+func (k *KV) Changed() (bool, error) {
+	return k.ChangedContext(context.Background())
+}
+
+//ChangedContext waits for change of keys with long-polling request of store client, that is canceled with context
+func (k *KV) ChangedContext(ctx context.Context) (bool, error) {
+	k.mu.Lock()
+	previous := k.index
+	k.mu.Unlock()
+
+	index, err := k.client.Wait(ctx, k.prefix, previous, k.waitTime)
+
+	if err != nil {
+		return false, fmt.Errorf("comfyconf: kv %q: %v", k.prefix, err)
+	}
+
+	return index != previous, nil
+}
+
+//optionName maps key of store to dotted full name. Folder keys, that end with `/`, are skipped
+func (k *KV) optionName(key string) (string, bool) {
+	if !strings.HasPrefix(key, k.prefix) || strings.HasSuffix(key, "/") {
+		return "", false
+	}
+
+	name := strings.Trim(strings.TrimPrefix(key, k.prefix), "/")
+
+	if len(name) == 0 {
+		return "", false
+	}
+
+	return strings.Replace(name, "/", ".", -1), true
+}
+
+//NewMemoryKV returns in-memory key-value store, that implements KVClient. Useful for tests
+func NewMemoryKV() *MemoryKV {
+	m := &MemoryKV{values: make(map[string][]byte), modified: make(map[string]uint64)}
+	m.changed = sync.NewCond(&m.mu)
+
+	return m
+}
+
+//MemoryKV in-memory key-value store. Every change increments index of store
+type MemoryKV struct {
+	mu       sync.Mutex
+	changed  *sync.Cond
+	values   map[string][]byte
+	modified map[string]uint64
+	index    uint64
+}
+
+//Put sets value of key
+func (m *MemoryKV) Put(key string, value string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.values[key] = []byte(value)
+	m.index++
+	m.modified[key] = m.index
+	m.changed.Broadcast()
+}
+
+//Delete removes key
+func (m *MemoryKV) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.values, key)
+	m.index++
+	m.modified[key] = m.index
+	m.changed.Broadcast()
+}
+
+//List returns pairs with keys starting with prefix sorted by keys
+func (m *MemoryKV) List(prefix string) ([]KVPair, uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pairs := make([]KVPair, 0)
+
+	for key, value := range m.values {
+		if strings.HasPrefix(key, prefix) {
+			pairs = append(pairs, KVPair{key, value})
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Key < pairs[j].Key
+	})
+
+	return pairs, m.index, nil
+}
+
+//Wait blocks until keys starting with prefix are changed after index, until wait time is elapsed or until
+//context is canceled. Changes of other keys do not stop waiting
+func (m *MemoryKV) Wait(ctx context.Context, prefix string, index uint64, waitTime time.Duration) (uint64, error) {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-time.After(waitTime):
+		case <-done:
+			return
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		m.changed.Broadcast()
+	}()
+
+	deadline := time.Now().Add(waitTime)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for !m.isChanged(prefix, index) && time.Now().Before(deadline) {
+		if err := ctx.Err(); err != nil {
+			return index, err
+		}
+
+		m.changed.Wait()
+	}
+
+	if m.isChanged(prefix, index) {
+		return m.index, nil
+	}
+
+	return index, ctx.Err()
+}
+
+//isChanged reports whether keys starting with prefix were changed after index
+func (m *MemoryKV) isChanged(prefix string, index uint64) bool {
+	for key, modified := range m.modified {
+		if modified > index && strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
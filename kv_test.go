@@ -0,0 +1,171 @@
+package comfyconf
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKV_Init(t *testing.T) {
+	store := NewMemoryKV()
+	store.Put("app/db/port", "5432")
+	store.Put("app/name", "app\n")
+	store.Put("app/hosts", "a\nb\n")
+	store.Put("app/folder/", "")
+	store.Put("other/name", "other")
+
+	k := NewKV(store, "app/")
+	assert.NoError(t, k.Init())
+	assert.Equal(t, "kv:app/", k.Name())
+
+	port, isOk := k.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 5432, port)
+
+	name, isOk := k.ParseString("n", "name")
+	assert.True(t, isOk)
+	assert.Equal(t, "app", name)
+
+	hosts, isOk := k.ParseSlice("h", "hosts")
+	assert.True(t, isOk)
+	assert.Equal(t, []interface{}{"a", "b"}, hosts)
+
+	_, isOk = k.ParseString("folder", "folder")
+	assert.False(t, isOk)
+}
+
+func TestKV_Changed(t *testing.T) {
+	store := NewMemoryKV()
+	store.Put("app/name", "app")
+
+	k := NewKV(store, "app/")
+	k.SetWaitTime(10 * time.Millisecond)
+	assert.NoError(t, k.Init())
+
+	changed, err := k.Changed()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		store.Put("app/name", "other")
+	}()
+
+	k.SetWaitTime(5 * time.Second)
+
+	changed, err = k.Changed()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+}
+
+func TestKV_Watch(t *testing.T) {
+	store := NewMemoryKV()
+	store.Put("app/log-level", "info")
+
+	conf := New(NewKV(store, "app/"))
+	level := conf.String("l", "log-level", "warn", "Log level")
+
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, "info", *level)
+
+	stop := make(chan struct{})
+	reloaded := make(chan error, 1)
+
+	conf.Watch(10*time.Millisecond, stop, func(err error) {
+		select {
+		case reloaded <- err:
+		default:
+		}
+	})
+	defer close(stop)
+
+	store.Delete("app/log-level")
+
+	select {
+	case err := <-reloaded:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("configuration was not reloaded")
+	}
+
+	conf.View(func() {
+		assert.Equal(t, "warn", *level)
+	})
+}
+
+func TestKV_Changed_OtherPrefix(t *testing.T) {
+	store := NewMemoryKV()
+	store.Put("app/name", "app")
+
+	k := NewKV(store, "app/")
+	k.SetWaitTime(50 * time.Millisecond)
+	assert.NoError(t, k.Init())
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		store.Put("other/name", "other")
+	}()
+
+	changed, err := k.Changed()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestKV_ChangedContext_Canceled(t *testing.T) {
+	store := NewMemoryKV()
+	store.Put("app/name", "app")
+
+	k := NewKV(store, "app/")
+	k.SetWaitTime(5 * time.Minute)
+	assert.NoError(t, k.Init())
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	started := time.Now()
+	changed, err := k.ChangedContext(ctx)
+	assert.EqualError(t, err, `comfyconf: kv "app/": context canceled`)
+	assert.False(t, changed)
+	assert.True(t, time.Since(started) < 5*time.Second)
+}
+
+func TestKV_Watch_Stop(t *testing.T) {
+	store := NewMemoryKV()
+	store.Put("app/log-level", "info")
+
+	k := NewKV(store, "app/")
+	k.SetWaitTime(5 * time.Minute)
+
+	conf := New(k)
+	level := conf.String("l", "log-level", "warn", "Log level")
+	assert.NoError(t, conf.Parse())
+
+	stop := make(chan struct{})
+	reloaded := make(chan error, 1)
+
+	conf.Watch(10*time.Millisecond, stop, func(err error) {
+		reloaded <- err
+	})
+
+	time.Sleep(10 * time.Millisecond)
+	close(stop)
+	time.Sleep(10 * time.Millisecond)
+
+	store.Put("app/log-level", "debug")
+
+	select {
+	case err := <-reloaded:
+		t.Fatalf("configuration was reloaded after stop: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	conf.View(func() {
+		assert.Equal(t, "info", *level)
+	})
+}
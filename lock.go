@@ -0,0 +1,74 @@
+package comfyconf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	lockRetryInterval = 10 * time.Millisecond
+	lockTimeout       = 30 * time.Second
+	//lockStaleAge is age of lock file, after which lock is treated as left by crashed process
+	lockStaleAge = 10 * time.Second
+)
+
+//lockPath returns path of hidden lock file, that is located next to file
+func lockPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lock")
+}
+
+//createLock acquires exclusive lock by creating lock file with process id, that is removed on release.
+//Lock file, that is older than lockStaleAge, was left by crashed process and is removed.
+//Returns function, that releases lock
+func createLock(path string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+
+		if err == nil {
+			_, err = file.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+			file.Close()
+
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+
+			return func() {
+				os.Remove(path)
+			}, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStaleAge {
+			os.Remove(path)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout waiting for lock %s held by process %s", path, lockOwner(path))
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+//lockOwner returns process id, that is written to lock file, or `unknown`
+func lockOwner(path string) string {
+	content, err := ioutil.ReadFile(path)
+
+	if err != nil || len(strings.TrimSpace(string(content))) == 0 {
+		return "unknown"
+	}
+
+	return strings.TrimSpace(string(content))
+}
@@ -0,0 +1,30 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package comfyconf
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+//lockFile acquires exclusive lock of directory of file, so no lock file is left next to it. Lock is released
+//by system, when process exits. Returns function, that releases lock
+func lockFile(path string) (func(), error) {
+	dir, err := os.Open(filepath.Dir(path))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(dir.Fd()), syscall.LOCK_EX); err != nil {
+		dir.Close()
+		return nil, err
+	}
+
+	return func() {
+		syscall.Flock(int(dir.Fd()), syscall.LOCK_UN)
+		dir.Close()
+	}, nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package comfyconf
+
+//lockFile acquires exclusive lock by creating hidden lock file next to file, like `.config.json.lock`.
+//Returns function, that releases lock
+func lockFile(path string) (func(), error) {
+	return createLock(lockPath(path))
+}
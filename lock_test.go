@@ -0,0 +1,57 @@
+package comfyconf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockFile(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{"config.json": `{}`})
+	defer os.RemoveAll(dir)
+
+	unlock, err := lockFile(filepath.Join(dir, "config.json"))
+	assert.NoError(t, err)
+	unlock()
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestCreateLock(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{})
+	defer os.RemoveAll(dir)
+
+	path := lockPath(filepath.Join(dir, "config.json"))
+	assert.Equal(t, filepath.Join(dir, ".config.json.lock"), path)
+
+	unlock, err := createLock(path)
+	assert.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid()), lockOwner(path))
+
+	unlock()
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCreateLock_Stale(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{".config.json.lock": "12345\n"})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".config.json.lock")
+	stale := time.Now().Add(-2 * lockStaleAge)
+	assert.NoError(t, os.Chtimes(path, stale, stale))
+
+	unlock, err := createLock(path)
+	assert.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid()), lockOwner(path))
+
+	unlock()
+}
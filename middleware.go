@@ -1,5 +1,10 @@
 package comfyconf
 
+import (
+	"context"
+	"reflect"
+)
+
 //Middleware interface for different configuration parsing. Can be used for external configuration parsers
 type Middleware interface {
 	//Initialization for Middleware
@@ -16,3 +21,51 @@ type Middleware interface {
 	//ParseSlice tries to get slice from Middleware by flag name and returns slice and fetching status
 	ParseSlice(shortName string, fullName string) ([]interface{}, bool)
 }
+
+//NamedMiddleware is optional interface for middlewares, that want to be identified by name as source of option values
+type NamedMiddleware interface {
+	//Name returns source name of Middleware
+	Name() string
+}
+
+//OriginMiddleware is optional interface for middlewares, that combine several sources, like files,
+//and can tell exact source of option value
+type OriginMiddleware interface {
+	//Origin returns source name of option value
+	Origin(shortName string, fullName string) (string, bool)
+}
+
+//middlewareSource returns exact source name of option value
+func middlewareSource(m Middleware, optKey OptionKey) string {
+	if origin, isOk := m.(OriginMiddleware); isOk {
+		if source, isOk := origin.Origin(optKey.shortName, optKey.fullName); isOk {
+			return source
+		}
+	}
+
+	return middlewareName(m)
+}
+
+func middlewareName(m Middleware) string {
+	if named, isOk := m.(NamedMiddleware); isOk {
+		return named.Name()
+	}
+
+	return reflect.TypeOf(m).String()
+}
+
+//WatchingMiddleware is optional interface for middlewares, that can detect changes of configuration source.
+//Used by Conf.Watch for hot reload
+type WatchingMiddleware interface {
+	//Changed reports whether source was changed since latest Init. Can block until change happens.
+	//Changed is called concurrently with Init, when configuration is reloaded
+	Changed() (bool, error)
+}
+
+//ContextWatchingMiddleware is optional interface for watching middlewares, that block until change happens.
+//Used by Conf.Watch instead of Changed, context is canceled, when watching is stopped
+type ContextWatchingMiddleware interface {
+	WatchingMiddleware
+	//ChangedContext reports whether source was changed since latest Init, waiting is canceled with context
+	ChangedContext(ctx context.Context) (bool, error)
+}
@@ -1,5 +1,12 @@
 package comfyconf
 
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
 //OptionKey key pair for indicating flag configuration
 type OptionKey struct {
 	shortName string
@@ -16,12 +23,28 @@ func (ok *OptionKey) GetFull() string {
 	return ok.fullName
 }
 
+//SourceDefault is the source name of options which value was not provided by any middleware
+const SourceDefault = "default"
+
 //Option structure that used for holding information about flags
 type Option struct {
+	key          OptionKey
+	index        int
+	group        string
 	defaultValue interface{}
 	variable     interface{}
 	optionType   OptionType
 	description  string
+	source       string
+	secret       bool
+	fileRef      bool
+	builtin      bool
+	validators   []Validator
+	required     bool
+	hint         ValueHint
+	completer    func(prefix string) []string
+	parsed       interface{}
+	encrypted    bool
 }
 
 //GetDescription returns option description
@@ -39,11 +62,33 @@ func (o *Option) GetDefaultValue() interface{} {
 	return o.defaultValue
 }
 
+//GetGroup returns name of option group, that is used as help section
+func (o *Option) GetGroup() string {
+	return o.group
+}
+
+//SetGroup sets name of option group, that is used as help section
+func (o *Option) SetGroup(group string) {
+	o.group = group
+}
+
+//GetSource returns name of source from where option value was taken
+func (o *Option) GetSource() string {
+	if len(o.source) == 0 {
+		return SourceDefault
+	}
+	return o.source
+}
+
 //Put binds value to variable
 func (o *Option) Put(value interface{}) {
 	if o.isOptionType(value) {
 		switch value.(type) {
 		case string:
+			if secret, isOk := o.variable.(*SecretString); isOk {
+				*secret = SecretString(value.(string))
+				return
+			}
 			*o.variable.(*string) = value.(string)
 		case int:
 			*o.variable.(*int) = value.(int)
@@ -55,6 +100,67 @@ func (o *Option) Put(value interface{}) {
 	}
 }
 
+//GetValue returns current value of option variable. Value of SecretString variable is returned as string
+func (o *Option) GetValue() interface{} {
+	if secret, isOk := o.variable.(*SecretString); isOk {
+		return secret.Reveal()
+	}
+
+	return reflect.ValueOf(o.variable).Elem().Interface()
+}
+
+//IsSecret returns true if option holds secret value
+func (o *Option) IsSecret() bool {
+	return o.secret
+}
+
+//SetSecret marks option as secret. Values of secret options are redacted in help, exports,
+//provenance explanations and error messages
+func (o *Option) SetSecret(secret bool) {
+	o.secret = secret
+}
+
+//String returns option name with its value and source. Secret values are redacted
+func (o *Option) String() string {
+	return fmt.Sprintf("%s = %s (source: %s)", o.key.fullName, o.valueString(), o.GetSource())
+}
+
+//DisplayDefault returns formatted default value, that can be shown in help. Secret values are redacted
+func (o *Option) DisplayDefault() string {
+	if o.secret {
+		return Redacted
+	}
+
+	return formatValue(o.defaultValue)
+}
+
+func (o *Option) valueString() string {
+	if o.secret {
+		return Redacted
+	}
+
+	return formatValue(o.GetValue())
+}
+
+func formatValue(value interface{}) string {
+	if s, isOk := value.(string); isOk {
+		return strconv.Quote(s)
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+func (o *Option) put(value interface{}, source string) bool {
+	if !o.isOptionType(value) {
+		return false
+	}
+
+	o.Put(value)
+	o.source = source
+
+	return true
+}
+
 func (o *Option) isOptionType(value interface{}) bool {
 	switch value.(type) {
 	case string:
@@ -80,3 +186,61 @@ const (
 	existenceType
 	sliceType
 )
+
+//convertString converts raw string value to type of option in same way as Flags middleware does
+func convertString(optionType OptionType, raw string) (interface{}, bool) {
+	switch optionType {
+	case stringType:
+		return raw, true
+	case intType:
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return 0, false
+		}
+		return v, true
+	case boolType:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return false, false
+		}
+		return v, true
+	case existenceType:
+		if len(raw) == 0 {
+			return false, true
+		}
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return false, false
+		}
+		return v, true
+	case sliceType:
+		v := make([]interface{}, 0)
+		if len(raw) == 0 {
+			return v, true
+		}
+		for _, item := range strings.Split(raw, ",") {
+			v = append(v, item)
+		}
+		return v, true
+	}
+
+	return nil, false
+}
+
+//String returns name of option type
+func (t OptionType) String() string {
+	switch t {
+	case intType:
+		return "int"
+	case stringType:
+		return "string"
+	case boolType:
+		return "bool"
+	case existenceType:
+		return "existence"
+	case sliceType:
+		return "slice"
+	}
+
+	return "unknown"
+}
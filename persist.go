@@ -0,0 +1,466 @@
+package comfyconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//SavingMiddleware is optional interface for middlewares, that can write option values back to their source
+type SavingMiddleware interface {
+	//Save writes values by full names of options
+	Save(values map[string]interface{}) error
+}
+
+//Persist writes values of options, that were changed after latest Parse, to source of middleware.
+//Options without full name and built-in options are not persisted. Values, that were in `ENC[...]` form,
+//are encrypted again with encrypter of Conf
+func (c *Conf) Persist(m Middleware) error {
+	saving, isOk := m.(SavingMiddleware)
+
+	if !isOk {
+		return fmt.Errorf("comfyconf: middleware %s does not support saving", middlewareName(m))
+	}
+
+	values := make(map[string]interface{})
+	changed := make([]*Option, 0)
+
+	for _, opt := range c.sortedOptions() {
+		if len(opt.key.fullName) == 0 || opt.builtin || reflect.DeepEqual(opt.parsed, opt.GetValue()) {
+			continue
+		}
+
+		value := opt.GetValue()
+
+		if opt.encrypted {
+			encrypted, err := c.encryptValue(opt, value)
+
+			if err != nil {
+				return err
+			}
+
+			value = encrypted
+		}
+
+		values[opt.key.fullName] = value
+		changed = append(changed, opt)
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	if err := saving.Save(values); err != nil {
+		return err
+	}
+
+	for _, opt := range changed {
+		opt.parsed = copyValue(opt.GetValue())
+	}
+
+	return nil
+}
+
+//snapshot remembers parsed values of options, that are used by Persist for detecting changes
+func (c *Conf) snapshot() {
+	for _, opt := range c.options {
+		opt.parsed = copyValue(opt.GetValue())
+	}
+}
+
+func copyValue(value interface{}) interface{} {
+	if items, isOk := value.([]interface{}); isOk && items != nil {
+		return append(make([]interface{}, 0, len(items)), items...)
+	}
+
+	return value
+}
+
+//Save writes values by full names to file, from where they were read, or to middleware file for new values.
+//Structure and key order of file are preserved. File is locked against concurrent writers, re-read and replaced
+//atomically through temporary file. Only standard JSON syntax can be saved
+func (j *JSON) Save(values map[string]interface{}) error {
+	return j.save(values, j.rootFile())
+}
+
+//Save writes values by full names to files, from where they were read. New values are written to latest file
+func (f *JSONFiles) Save(values map[string]interface{}) error {
+	if len(f.files) == 0 {
+		return fmt.Errorf("comfyconf: no files to save values")
+	}
+
+	return f.save(values, f.files[len(f.files)-1])
+}
+
+func (j *JSON) save(values map[string]interface{}, defaultFile fileID) error {
+	if len(j.format) != 0 {
+		return fmt.Errorf("comfyconf: saving is not supported for %s files", j.format)
+	}
+
+	if j.syntax != SyntaxJSON {
+		return fmt.Errorf("comfyconf: saving is supported only for standard JSON syntax")
+	}
+
+	files := make(map[fileID]map[string]interface{})
+
+	for key, value := range values {
+		file, section := defaultFile, ""
+
+		//values of profile section, like `profiles.prod`, are written to section
+		if origin, isOk := j.origins[key]; isOk {
+			file = origin.file
+
+			if len(origin.section) != 0 {
+				section = origin.section + "."
+			}
+		}
+
+		if len(file.path) == 0 {
+			return fmt.Errorf("comfyconf: no file to save option %q", key)
+		}
+
+		if files[file] == nil {
+			files[file] = make(map[string]interface{})
+		}
+
+		files[file][section+key] = value
+	}
+
+	ids := make([]fileID, 0, len(files))
+
+	for file := range files {
+		ids = append(ids, file)
+	}
+
+	sort.Slice(ids, func(a, b int) bool {
+		if ids[a].path != ids[b].path {
+			return ids[a].path < ids[b].path
+		}
+
+		return ids[a].fs < ids[b].fs
+	})
+
+	for _, file := range ids {
+		if err := j.checkWritable(file); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range ids {
+		if err := saveJSONFile(file.path, files[file]); err != nil {
+			return fmt.Errorf("comfyconf: %s: %v", file.path, err)
+		}
+	}
+
+	return nil
+}
+
+//saveJSONFile sets values in JSON file under file lock
+func saveJSONFile(path string, values map[string]interface{}) error {
+	unlock, err := lockFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	content, err := ioutil.ReadFile(path)
+	mode := os.FileMode(0644)
+
+	switch {
+	case err == nil:
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+	case os.IsNotExist(err):
+		content = []byte("{}")
+	default:
+		return err
+	}
+
+	if IsEncrypted(string(bytes.TrimSpace(content))) {
+		return errors.New("encrypted file can not be saved")
+	}
+
+	root, err := decodeOrdered(content)
+
+	if err != nil {
+		return err
+	}
+
+	object, isOk := root.(*orderedObject)
+
+	if !isOk {
+		return fmt.Errorf("root value is not object")
+	}
+
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := object.set(strings.Split(key, "."), values[key]); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	}
+
+	var buffer bytes.Buffer
+
+	if err := writeOrdered(&buffer, object, detectIndent(content), ""); err != nil {
+		return err
+	}
+
+	buffer.WriteString("\n")
+
+	return writeFileAtomic(path, buffer.Bytes(), mode)
+}
+
+//writeFileAtomic writes content to temporary file in same directory and renames it to path
+func writeFileAtomic(path string, content []byte, mode os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+//orderedObject JSON object, that keeps order of keys
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+//set sets value by path, missing objects are created. Items of arrays are addressed by index
+func (o *orderedObject) set(path []string, value interface{}) error {
+	key := path[0]
+
+	if len(path) == 1 {
+		if _, isExist := o.values[key]; !isExist {
+			o.keys = append(o.keys, key)
+		}
+
+		o.values[key] = value
+
+		return nil
+	}
+
+	child, isExist := o.values[key]
+
+	if !isExist {
+		child = &orderedObject{values: make(map[string]interface{})}
+		o.keys = append(o.keys, key)
+		o.values[key] = child
+	}
+
+	return setOrdered(child, path[1:], value)
+}
+
+func setOrdered(node interface{}, path []string, value interface{}) error {
+	switch v := node.(type) {
+	case *orderedObject:
+		return v.set(path, value)
+	case []interface{}:
+		i, err := strconv.Atoi(path[0])
+
+		if err != nil || i < 0 || i >= len(v) {
+			return fmt.Errorf("invalid index %q", path[0])
+		}
+
+		if len(path) == 1 {
+			v[i] = value
+			return nil
+		}
+
+		return setOrdered(v[i], path[1:], value)
+	}
+
+	return fmt.Errorf("%q is not object", path[0])
+}
+
+//decodeOrdered decodes JSON value with objects, that keep order of keys, and numbers, that keep their format
+func decodeOrdered(content []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	var decode func() (interface{}, error)
+
+	decode = func() (interface{}, error) {
+		token, err := decoder.Token()
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch token {
+		case json.Delim('{'):
+			object := &orderedObject{values: make(map[string]interface{})}
+
+			for decoder.More() {
+				key, err := decoder.Token()
+
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := decode()
+
+				if err != nil {
+					return nil, err
+				}
+
+				if _, isExist := object.values[key.(string)]; !isExist {
+					object.keys = append(object.keys, key.(string))
+				}
+
+				object.values[key.(string)] = value
+			}
+
+			_, err = decoder.Token()
+
+			return object, err
+		case json.Delim('['):
+			items := make([]interface{}, 0)
+
+			for decoder.More() {
+				item, err := decode()
+
+				if err != nil {
+					return nil, err
+				}
+
+				items = append(items, item)
+			}
+
+			_, err = decoder.Token()
+
+			return items, err
+		}
+
+		return token, nil
+	}
+
+	return decode()
+}
+
+//writeOrdered writes JSON value with indentation
+func writeOrdered(w io.Writer, value interface{}, indent string, prefix string) error {
+	switch v := value.(type) {
+	case *orderedObject:
+		if len(v.keys) == 0 {
+			_, err := io.WriteString(w, "{}")
+			return err
+		}
+
+		io.WriteString(w, "{\n")
+
+		for i, key := range v.keys {
+			io.WriteString(w, prefix+indent+marshalJSON(key)+": ")
+
+			if err := writeOrdered(w, v.values[key], indent, prefix+indent); err != nil {
+				return err
+			}
+
+			if i != len(v.keys)-1 {
+				io.WriteString(w, ",")
+			}
+
+			io.WriteString(w, "\n")
+		}
+
+		_, err := io.WriteString(w, prefix+"}")
+
+		return err
+	case []interface{}:
+		if len(v) == 0 {
+			_, err := io.WriteString(w, "[]")
+			return err
+		}
+
+		io.WriteString(w, "[\n")
+
+		for i, item := range v {
+			io.WriteString(w, prefix+indent)
+
+			if err := writeOrdered(w, item, indent, prefix+indent); err != nil {
+				return err
+			}
+
+			if i != len(v)-1 {
+				io.WriteString(w, ",")
+			}
+
+			io.WriteString(w, "\n")
+		}
+
+		_, err := io.WriteString(w, prefix+"]")
+
+		return err
+	}
+
+	_, err := io.WriteString(w, marshalJSON(value))
+
+	return err
+}
+
+//marshalJSON returns JSON of scalar value without escaping of HTML characters
+func marshalJSON(value interface{}) string {
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); err != nil {
+		return "null"
+	}
+
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+//detectIndent returns indentation of first indented line or two spaces
+func detectIndent(content []byte) string {
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+
+		if len(trimmed) != len(line) && len(trimmed) != 0 {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+
+	return "  "
+}
@@ -0,0 +1,211 @@
+package comfyconf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConf_Persist(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json": "{\n    \"name\": \"app\",\n    \"db\": {\n        \"port\": 5432,\n        \"host\": \"localhost\"\n    },\n" +
+			"    \"ratio\": 1.50,\n    \"servers\": [{\"host\": \"a\"}]\n}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	j := NewJSON(path)
+
+	conf := New(j)
+	port := conf.Int("", "db.port", 0, "Port")
+	host := conf.String("", "db.host", "", "Host")
+	user := conf.String("", "db.user", "", "User")
+	tags := conf.Slice("", "tags", nil, "Tags")
+	server := conf.String("", "servers.0.host", "", "Server")
+	conf.Int("", "timeout", 30, "Timeout")
+
+	assert.NoError(t, conf.Parse())
+	assert.NoError(t, conf.Persist(j))
+
+	*port = 6543
+	*user = "admin<1>"
+	*tags = []interface{}{"a", "b"}
+	*server = "b"
+
+	assert.NoError(t, conf.Persist(j))
+
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+    "name": "app",
+    "db": {
+        "port": 6543,
+        "host": "localhost",
+        "user": "admin<1>"
+    },
+    "ratio": 1.50,
+    "servers": [
+        {
+            "host": "b"
+        }
+    ],
+    "tags": [
+        "a",
+        "b"
+    ]
+}
+`, string(content))
+
+	assert.Equal(t, "localhost", *host)
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, 6543, *port)
+	assert.Equal(t, "admin<1>", *user)
+
+	assert.Error(t, conf.Persist(NewFlags()))
+}
+
+func TestJSON_Save_Concurrent(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json": `{}`,
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+
+	var wg sync.WaitGroup
+
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		wg.Add(1)
+
+		go func(key string) {
+			defer wg.Done()
+			assert.NoError(t, NewJSON(path).Save(map[string]interface{}{"keys." + key: true}))
+		}(key)
+	}
+
+	wg.Wait()
+
+	j := NewJSON(path)
+	assert.NoError(t, j.Init())
+
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		v, isOk := j.ParseBool("", "keys."+key)
+		assert.True(t, isOk, key)
+		assert.True(t, v, key)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, ".config.json.tmp*"))
+	assert.Empty(t, matches)
+}
+
+func TestJSON_Save_Profile(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json": `{"level": "info", "profiles": {"prod": {"level": "warn"}}}`,
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	j := NewJSON(path)
+
+	conf := New(j)
+	conf.Profile("", "profile", "prod", "Profile")
+	level := conf.String("", "level", "", "Level")
+
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, "warn", *level)
+
+	*level = "error"
+	assert.NoError(t, conf.Persist(j))
+
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"level": "info", "profiles": {"prod": {"level": "error"}}}`, string(content))
+
+	j.SetSyntax(SyntaxJSONC)
+	assert.Error(t, j.Save(map[string]interface{}{"level": "debug"}))
+}
+
+func TestJSON_Save_HashInPath(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"a#b/config.json": `{"level": "info", "port": 80, "profiles": {"prod": {"level": "warn"}}}`,
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "a#b", "config.json")
+	j := NewJSON(path)
+
+	conf := New(j)
+	conf.Profile("", "profile", "prod", "Profile")
+	level := conf.String("", "level", "", "Level")
+	port := conf.Int("", "port", 0, "Port")
+
+	assert.NoError(t, conf.Parse())
+
+	origin, _ := j.Origin("", "level")
+	assert.Equal(t, "json:"+path+"#profiles.prod", origin)
+
+	origin, _ = j.Origin("", "port")
+	assert.Equal(t, "json:"+path, origin)
+
+	*level = "error"
+	*port = 8080
+	assert.NoError(t, conf.Persist(j))
+
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"level": "info", "port": 8080, "profiles": {"prod": {"level": "error"}}}`, string(content))
+
+	_, err = os.Stat(filepath.Join(dir, "a"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestConf_Persist_Encrypted(t *testing.T) {
+	a, _ := NewAESGCM(make([]byte, 32))
+	password, _ := EncryptValue(a, []byte("secret"))
+	token, _ := EncryptValue(a, []byte("token"))
+
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json": `{"db": {"password": "` + password + `"}, "tokens": ["plain", "` + token + `"], "name": "app"}`,
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	j := NewJSON(path)
+
+	conf := New(j)
+	conf.SetDecrypter(a)
+	dbPassword := conf.Secret("", "db.password", "", "Password")
+	tokens := conf.Slice("", "tokens", nil, "Tokens")
+	name := conf.String("", "name", "", "Name")
+
+	assert.NoError(t, conf.Parse())
+
+	*dbPassword = "changed"
+	*tokens = []interface{}{"plain", "other"}
+	*name = "renamed"
+
+	assert.EqualError(t, conf.Persist(j), `comfyconf: option "db.password" is encrypted, but encrypter is not set`)
+
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "changed")
+	assert.Contains(t, string(content), `"app"`)
+
+	conf.SetEncrypter(a)
+	assert.NoError(t, conf.Persist(j))
+
+	content, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "changed")
+	assert.NotContains(t, string(content), "other")
+	assert.Contains(t, string(content), `"renamed"`)
+
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, "changed", dbPassword.Reveal())
+	assert.Equal(t, []interface{}{"plain", "other"}, *tokens)
+	assert.Equal(t, "renamed", *name)
+}
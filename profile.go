@@ -0,0 +1,228 @@
+package comfyconf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const profilesKey = "profiles"
+
+//ProfileMiddleware is optional interface for middlewares, that provide profile specific overlays
+type ProfileMiddleware interface {
+	//SetProfile activates overlay of selected profile. Called by Conf.Parse after Init, profile can be empty
+	SetProfile(profile string) error
+}
+
+//ProfileVar defines option, that selects active configuration profile, like `dev`, `staging` or `prod`,
+//and binds provided string pointer to it. Profile option is parsed before other options and activates
+//profile overlays of middlewares, that implement ProfileMiddleware interface
+func (c *Conf) ProfileVar(shortName string, fullName string, defaultValue string, variable *string, description string) {
+	c.StringVar(shortName, fullName, defaultValue, variable, description)
+	c.profileKey = &OptionKey{shortName, fullName}
+}
+
+//Profile defines option, that selects active configuration profile, and returns pointer to its value.
+//Use `--profile=prod` or `ENV_PROFILE=prod` for selecting profile with `profile` option name
+func (c *Conf) Profile(shortName string, fullName string, defaultValue string, description string) *string {
+	variable := new(string)
+	c.ProfileVar(shortName, fullName, defaultValue, variable, description)
+	return variable
+}
+
+//ActiveProfile returns profile, that was selected during latest Parse
+func (c *Conf) ActiveProfile() string {
+	return c.profile
+}
+
+//isProfileOption checks, that option selects configuration profile
+func (c *Conf) isProfileOption(opt *Option) bool {
+	return c.profileKey != nil && opt.key == *c.profileKey
+}
+
+//applyProfile parses profile option and activates selected profile in all middlewares
+func (c *Conf) applyProfile() error {
+	c.profile = ""
+
+	if c.profileKey == nil {
+		return nil
+	}
+
+	opt, isOk := c.options[*c.profileKey]
+
+	if !isOk {
+		return nil
+	}
+
+	for _, m := range c.middleware {
+		if env, isOk := m.(*Env); isOk {
+			env.setUpperCaseName(c.profileKey.fullName)
+		}
+	}
+
+	if err := c.parseOption(*c.profileKey, opt); err != nil {
+		return err
+	}
+
+	c.profile = opt.GetValue().(string)
+
+	for _, m := range c.middleware {
+		if profiled, isOk := m.(ProfileMiddleware); isOk {
+			if err := profiled.SetProfile(c.profile); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+//SetProfile overlays values from `profiles.<profile>` section and from `<name>.<profile>.json` file,
+//that is located next to configuration file. Profile section is removed from configuration.
+//Configuration with overlays is validated against schema again
+func (j *JSON) SetProfile(profile string) error {
+	files := make([]fileID, 0, 1)
+
+	if len(j.path) != 0 {
+		files = append(files, j.rootFile())
+	}
+
+	return j.applyProfile(profile, files)
+}
+
+//SetProfile overlays values from `profiles.<profile>` section and from `<name>.<profile>.json` files,
+//that are located next to every read file. Profile section is removed from configuration
+func (f *JSONFiles) SetProfile(profile string) error {
+	return f.applyProfile(profile, f.files)
+}
+
+func (j *JSON) applyProfile(profile string, files []fileID) error {
+	sectionPrefix := profilesKey + "." + profile + "."
+	overlay := make(map[string]interface{})
+
+	for key, value := range j.parsed {
+		if !strings.HasPrefix(key, profilesKey+".") {
+			continue
+		}
+
+		delete(j.parsed, key)
+
+		if len(profile) != 0 && strings.HasPrefix(key, sectionPrefix) {
+			overlay[strings.TrimPrefix(key, sectionPrefix)] = value
+		}
+	}
+
+	for key, value := range overlay {
+		origin := j.origins[sectionPrefix+key]
+
+		if len(origin.file.path) == 0 {
+			origin.file = j.rootFile()
+		}
+
+		origin.section = profilesKey + "." + profile
+		j.overlay(key, value, origin)
+	}
+
+	if len(profile) == 0 {
+		j.prepareIndex()
+		return nil
+	}
+
+	effective := j.profileTree(profile)
+
+	for _, file := range files {
+		profileFile := file.related(profilePath(file.path, profile))
+
+		content, err := j.readFile(profileFile)
+
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return err
+		}
+
+		tmpParsed, origins, err := j.decode(profileFile, content, []fileID{profileFile})
+
+		if err != nil {
+			return err
+		}
+
+		for key, value := range j.parse(tmpParsed) {
+			j.overlay(key, value, origins[key])
+		}
+
+		mergeJSON(effective, tmpParsed, "", nil)
+	}
+
+	if err := j.validateSchema(effective, j.origins); err != nil {
+		return err
+	}
+
+	j.prepareIndex()
+
+	return nil
+}
+
+//profileTree returns copy of decoded configuration with profile section merged in place of all profile sections.
+//Profile files are merged into it later, so schema is checked against configuration with all overlays
+func (j *JSON) profileTree(profile string) map[string]interface{} {
+	tree := make(map[string]interface{}, len(j.tree))
+
+	for key, value := range j.tree {
+		if key != profilesKey {
+			tree[key] = copyJSON(value)
+		}
+	}
+
+	profiles, _ := j.tree[profilesKey].(map[string]interface{})
+
+	if section, isOk := profiles[profile].(map[string]interface{}); isOk {
+		mergeJSON(tree, copyJSON(section).(map[string]interface{}), "", nil)
+	}
+
+	return tree
+}
+
+//copyJSON returns deep copy of decoded JSON value
+func copyJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+
+		for key, item := range v {
+			result[key] = copyJSON(item)
+		}
+
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+
+		for i, item := range v {
+			result[i] = copyJSON(item)
+		}
+
+		return result
+	default:
+		return v
+	}
+}
+
+//overlay replaces value of flattened key and all nested keys
+func (j *JSON) overlay(key string, value interface{}, origin valueOrigin) {
+	for existing := range j.parsed {
+		if strings.HasPrefix(existing, key+".") || strings.HasPrefix(key, existing+".") {
+			delete(j.parsed, existing)
+		}
+	}
+
+	j.parsed[key] = value
+	j.origins[key] = origin
+}
+
+//profilePath returns path of profile file, `config.json` becomes `config.prod.json`
+func profilePath(path string, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
+}
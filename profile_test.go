@@ -0,0 +1,122 @@
+package comfyconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConf_Profile_Section(t *testing.T) {
+	conf := New(NewJSONWithCustomReader(func(j *JSON) ([]byte, error) {
+		if len(j.path) != 0 {
+			return nil, os.ErrNotExist
+		}
+
+		return []byte(`{
+			"db": {"host": "localhost", "port": 5432},
+			"profiles": {
+				"prod": {"db": {"host": "db.prod"}},
+				"dev": {"debug": true}
+			}
+		}`), nil
+	}), prepareFlags([]string{"--profile=prod"}, "="))
+
+	profile := conf.Profile("", "profile", "dev", "Configuration profile")
+	host := conf.String("h", "db.host", "", "Database host")
+	port := conf.Int("p", "db.port", 0, "Database port")
+	debug := conf.Bool("d", "debug", false, "Debug")
+
+	assert.NoError(t, conf.Parse())
+
+	assert.Equal(t, "prod", *profile)
+	assert.Equal(t, "prod", conf.ActiveProfile())
+	assert.Equal(t, "db.prod", *host)
+	assert.Equal(t, 5432, *port)
+	assert.False(t, *debug)
+	assert.Equal(t, "json:#profiles.prod", conf.Lookup("db.host").GetSource())
+}
+
+func TestConf_Profile_File(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json":      `{"db": {"host": "localhost", "port": 5432}, "profiles": {"prod": {"db": {"port": 6432}}}}`,
+		"config.prod.json": `{"db": {"host": "db.prod"}}`,
+	})
+	defer os.RemoveAll(dir)
+
+	_ = os.Setenv("PROFILE_TEST_PROFILE", "prod")
+	defer os.Unsetenv("PROFILE_TEST_PROFILE")
+
+	path := filepath.Join(dir, "config.json")
+	conf := New(NewJSON(path), NewEnvWithPrefix("PROFILE_TEST_"), prepareFlags([]string{"--db.port=7432"}, "="))
+
+	conf.Profile("", "profile", "", "Configuration profile")
+	host := conf.String("h", "db.host", "", "Database host")
+	port := conf.Int("p", "db.port", 0, "Database port")
+
+	assert.NoError(t, conf.Parse())
+
+	assert.Equal(t, "prod", conf.ActiveProfile())
+	assert.Equal(t, "db.prod", *host)
+	assert.Equal(t, 7432, *port)
+
+	assert.Equal(t, "json:"+filepath.Join(dir, "config.prod.json"), conf.Lookup("db.host").GetSource())
+
+	explanation, _ := conf.Explain("db.host")
+	assert.Contains(t, explanation, "config.prod.json")
+}
+
+func TestJSONFiles_SetProfile(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json":          `{"level": "info", "port": 80}`,
+		"conf.d/cache.json":    `{"cache": "memory"}`,
+		"conf.d/cache.ci.json": `{"cache": "none"}`,
+	})
+	defer os.RemoveAll(dir)
+
+	f := NewJSONFiles(RequiredFile(filepath.Join(dir, "config.json")), OptionalFile(filepath.Join(dir, "conf.d", "cache.json")))
+
+	assert.NoError(t, f.Init())
+	assert.NoError(t, f.SetProfile("ci"))
+
+	cache, isOk := f.ParseString("cache", "cache")
+	assert.True(t, isOk)
+	assert.Equal(t, "none", cache)
+
+	assert.NoError(t, f.Init())
+	assert.NoError(t, f.SetProfile(""))
+
+	cache, _ = f.ParseString("cache", "cache")
+	assert.Equal(t, "memory", cache)
+}
+
+func TestJSONFiles_SetProfile_Glob(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"conf.d/cache.json":      `{"cache": "memory"}`,
+		"conf.d/cache.prod.json": `{"cache": "redis"}`,
+		"conf.d/log.json":        `{"level": "info"}`,
+		"conf.d/zz.ci.json":      `{"level": "debug"}`,
+	})
+	defer os.RemoveAll(dir)
+
+	f := NewJSONFiles(RequiredFile(filepath.Join(dir, "conf.d", "*.json")))
+
+	assert.NoError(t, f.Init())
+	assert.NoError(t, f.SetProfile(""))
+
+	assert.Equal(t, []string{
+		filepath.Join(dir, "conf.d", "cache.json"),
+		filepath.Join(dir, "conf.d", "log.json"),
+		filepath.Join(dir, "conf.d", "zz.ci.json"),
+	}, f.Files())
+
+	cache, _ := f.ParseString("cache", "cache")
+	assert.Equal(t, "memory", cache)
+
+	assert.NoError(t, f.Init())
+	assert.NoError(t, f.SetProfile("prod"))
+
+	cache, _ = f.ParseString("cache", "cache")
+	assert.Equal(t, "redis", cache)
+}
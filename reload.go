@@ -0,0 +1,91 @@
+package comfyconf
+
+import (
+	"context"
+	"time"
+)
+
+//Reload initializes middlewares again and populates all options with fresh values.
+//Options, that are not provided by any middleware anymore, get their default values.
+//Errors are always returned, error handling mode of Conf is applied only by Parse
+func (c *Conf) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.parse()
+}
+
+//View calls fn while configuration is not reloaded, so values of options and bound variables can be read
+//consistently, when Watch is running
+func (c *Conf) View(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fn()
+}
+
+//Watch starts watching all middlewares, that implement WatchingMiddleware interface, and reloads configuration
+//when any of them is changed. Sources are checked with provided interval, onReload is called after every reload
+//or failed check. Watching stops, when stop channel is closed.
+//Every middleware is checked by its own goroutine, reloads are serialized with each other and with View.
+//Changed can be called concurrently with Init of reload, so middlewares guard state shared by them
+func (c *Conf) Watch(interval time.Duration, stop <-chan struct{}, onReload func(err error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for _, m := range c.middleware {
+		watching, isOk := m.(WatchingMiddleware)
+
+		if !isOk {
+			continue
+		}
+
+		go c.watchOne(ctx, watching, interval, onReload)
+	}
+}
+
+func (c *Conf) watchOne(ctx context.Context, watching WatchingMiddleware, interval time.Duration, onReload func(err error)) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		changed, err := isChanged(ctx, watching)
+
+		//waiting was canceled, because watching is stopped
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil && changed {
+			err = c.Reload()
+		}
+
+		if (err != nil || changed) && onReload != nil {
+			onReload(err)
+		}
+
+		if changed && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+//isChanged checks middleware for changes, blocking checks are canceled with context
+func isChanged(ctx context.Context, watching WatchingMiddleware) (bool, error) {
+	if contextWatching, isOk := watching.(ContextWatchingMiddleware); isOk {
+		return contextWatching.ChangedContext(ctx)
+	}
+
+	return watching.Changed()
+}
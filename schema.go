@@ -0,0 +1,171 @@
+package comfyconf
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//JSONSchemaDraft identifier of JSON Schema dialect, that is generated by Conf.JSONSchema
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+//JSONSchema returns JSON Schema of configuration file, that can be used by editors and CI for validation.
+//Nested objects are shaped from dotted full names same way as JSON middleware flattens them. Schema includes
+//types, defaults, descriptions, enums of OneOf validators, bounds of Min, Max and Range validators and
+//required options. Options without full name, built-in options and defaults of secrets are skipped
+func (c *Conf) JSONSchema() ([]byte, error) {
+	root := newSchemaObject()
+	root["$schema"] = JSONSchemaDraft
+	root["title"] = c.programName()
+
+	if len(c.description) != 0 {
+		root["description"] = firstLine(c.description)
+	}
+
+	for _, opt := range c.sortedOptions() {
+		if len(opt.key.fullName) == 0 || opt.builtin {
+			continue
+		}
+
+		path := strings.Split(opt.key.fullName, ".")
+		node := root
+
+		for i, part := range path[:len(path)-1] {
+			properties := node["properties"].(map[string]interface{})
+			child, isExist := properties[part]
+
+			if !isExist {
+				child = newSchemaObject()
+				properties[part] = child
+			}
+
+			childNode, isOk := child.(map[string]interface{})
+
+			if !isOk || childNode["type"] != "object" {
+				return nil, fmt.Errorf("comfyconf: option %q conflicts with option %q", opt.key.fullName, strings.Join(path[:i+1], "."))
+			}
+
+			if opt.required {
+				addSchemaRequired(node, part)
+			}
+
+			node = childNode
+		}
+
+		last := path[len(path)-1]
+		properties := node["properties"].(map[string]interface{})
+
+		if _, isExist := properties[last]; isExist {
+			return nil, fmt.Errorf("comfyconf: option %q conflicts with nested options", opt.key.fullName)
+		}
+
+		properties[last] = opt.jsonSchema()
+
+		if opt.required {
+			addSchemaRequired(node, last)
+		}
+	}
+
+	return json.MarshalIndent(root, "", "  ")
+}
+
+//jsonSchema returns schema of option value
+func (o *Option) jsonSchema() map[string]interface{} {
+	schema := make(map[string]interface{})
+	constraints := schema
+
+	switch o.optionType {
+	case intType:
+		schema["type"] = "integer"
+	case stringType:
+		schema["type"] = "string"
+	case boolType, existenceType:
+		schema["type"] = "boolean"
+	case sliceType:
+		constraints = make(map[string]interface{})
+		schema["type"] = "array"
+		schema["items"] = constraints
+	}
+
+	if len(o.description) != 0 {
+		schema["description"] = o.description
+	}
+
+	if o.secret {
+		schema["writeOnly"] = true
+	} else if def := o.schemaDefault(); def != nil {
+		schema["default"] = def
+	}
+
+	if values := o.enumValues(); len(values) != 0 {
+		enum := make([]interface{}, 0, len(values))
+
+		for _, value := range values {
+			if converted, isOk := convertString(o.optionType, value); isOk && o.optionType != sliceType {
+				enum = append(enum, converted)
+			} else {
+				enum = append(enum, value)
+			}
+		}
+
+		constraints["enum"] = enum
+	}
+
+	for _, validator := range o.validators {
+		if rangeValidator, isOk := validator.(*RangeValidator); isOk {
+			min, max := rangeValidator.Bounds()
+
+			if min != nil {
+				constraints["minimum"] = *min
+			}
+
+			if max != nil {
+				constraints["maximum"] = *max
+			}
+		}
+	}
+
+	return schema
+}
+
+//schemaDefault returns default value of option or nil, if option has no meaningful default
+func (o *Option) schemaDefault() interface{} {
+	switch v := o.defaultValue.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+	}
+
+	if o.optionType == existenceType {
+		return nil
+	}
+
+	return o.defaultValue
+}
+
+func newSchemaObject() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": make(map[string]interface{}),
+	}
+}
+
+//addSchemaRequired adds property to sorted list of required properties of object
+func addSchemaRequired(node map[string]interface{}, name string) {
+	required, _ := node["required"].([]string)
+
+	for _, existing := range required {
+		if existing == name {
+			return
+		}
+	}
+
+	required = append(required, name)
+	sort.Strings(required)
+
+	node["required"] = required
+}
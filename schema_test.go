@@ -0,0 +1,65 @@
+package comfyconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConf_JSONSchema(t *testing.T) {
+	conf := prepareConf([]string{}, "=")
+	conf.SetProgram("server", "")
+	conf.SetDescription("Runs HTTP server")
+
+	conf.String("", "db.host", "localhost", "Database host")
+	conf.Int("p", "db.port", 5432, "Database port")
+	conf.AddValidator("db.port", Range(1, 65535))
+	conf.Secret("", "db.password", "changeme", "Database password")
+	conf.String("l", "log.level", "info", "Log level")
+	conf.AddValidator("log.level", OneOf("debug", "info"))
+	conf.Slice("", "tags", nil, "Tags")
+	conf.AddValidator("tags", OneOf("a", "b"))
+	conf.Bool("", "debug", false, "Debug mode")
+	conf.Int("w", "", 1, "Workers")
+
+	conf.SetRequired("db.host", "tags")
+
+	schema, err := conf.JSONSchema()
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "server",
+		"description": "Runs HTTP server",
+		"type": "object",
+		"required": ["db", "tags"],
+		"properties": {
+			"db": {
+				"type": "object",
+				"required": ["host"],
+				"properties": {
+					"host": {"type": "string", "description": "Database host", "default": "localhost"},
+					"port": {"type": "integer", "description": "Database port", "default": 5432, "minimum": 1, "maximum": 65535},
+					"password": {"type": "string", "description": "Database password", "writeOnly": true}
+				}
+			},
+			"log": {
+				"type": "object",
+				"properties": {
+					"level": {"type": "string", "description": "Log level", "default": "info", "enum": ["debug", "info"]}
+				}
+			},
+			"tags": {"type": "array", "description": "Tags", "items": {"enum": ["a", "b"]}},
+			"debug": {"type": "boolean", "description": "Debug mode", "default": false}
+		}
+	}`, string(schema))
+}
+
+func TestConf_JSONSchema_Conflict(t *testing.T) {
+	conf := prepareConf([]string{}, "=")
+	conf.String("", "db", "", "Database")
+	conf.String("", "db.host", "", "Database host")
+
+	_, err := conf.JSONSchema()
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package comfyconf
+
+const (
+	secretTagName string = "secret"
+
+	//Redacted is placeholder, that is shown instead of secret values
+	Redacted = "******"
+)
+
+//SecretString string type, that hides its value from fmt, JSON and text output.
+//Use Reveal for getting real value
+type SecretString string
+
+//Reveal returns real value of secret string
+func (s SecretString) Reveal() string {
+	return string(s)
+}
+
+//String returns redacted value
+func (s SecretString) String() string {
+	return Redacted
+}
+
+//GoString returns redacted value for %#v format
+func (s SecretString) GoString() string {
+	return Redacted
+}
+
+//MarshalText returns redacted value
+func (s SecretString) MarshalText() ([]byte, error) {
+	return []byte(Redacted), nil
+}
+
+//SecretVar defines secret string option with selected fullname, shortname, default value and description,
+//binds provided secret string pointer to flag.
+func (c *Conf) SecretVar(shortName string, fullName string, defaultValue string, variable *SecretString, description string) {
+	*variable = SecretString(defaultValue)
+	c.createOption(shortName, fullName, defaultValue, variable, stringType, description)
+	c.options[OptionKey{shortName, fullName}].secret = true
+}
+
+//Secret defines secret string option with selected fullname, shortname, default value and description,
+//creates and returns pointer to secret string variable and binds that variable to flag.
+func (c *Conf) Secret(shortName string, fullName string, defaultValue string, description string) *SecretString {
+	variable := new(SecretString)
+	c.SecretVar(shortName, fullName, defaultValue, variable, description)
+	return variable
+}
+
+//SetSecret marks options with provided full or short names as secret. Values of secret options are redacted
+//in help, exports, provenance explanations and error messages
+func (c *Conf) SetSecret(names ...string) {
+	for _, name := range names {
+		if opt := c.Lookup(name); opt != nil {
+			opt.SetSecret(true)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package comfyconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretString_Format(t *testing.T) {
+	s := SecretString("hunter2")
+
+	assert.Equal(t, "hunter2", s.Reveal())
+	assert.Equal(t, Redacted, fmt.Sprint(s))
+	assert.NotContains(t, fmt.Sprintf("%v %s %#v %q", s, s, s, s), "hunter2")
+
+	content, err := json.Marshal(struct{ Password SecretString }{s})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Password":"******"}`, string(content))
+}
+
+func TestConf_Secret(t *testing.T) {
+	conf := prepareConf([]string{"--password=hunter2"}, "=")
+
+	password := conf.Secret("p", "password", "changeme", "Database password")
+
+	assert.Equal(t, "changeme", password.Reveal())
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, "hunter2", password.Reveal())
+
+	opt := conf.Lookup("password")
+	assert.True(t, opt.IsSecret())
+	assert.Equal(t, "hunter2", opt.GetValue())
+	assert.Equal(t, Redacted, opt.DisplayDefault())
+	assert.Equal(t, "password = ****** (source: flags)", fmt.Sprint(opt))
+
+	explanation, isOk := conf.Explain("p")
+	assert.True(t, isOk)
+	assert.NotContains(t, explanation, "hunter2")
+}
+
+func TestConf_SetSecret_Export(t *testing.T) {
+	conf := prepareConf([]string{"--token=abc", "--user=admin"}, "=")
+
+	conf.String("t", "token", "", "API token")
+	conf.String("u", "user", "", "User")
+	conf.SetSecret("token")
+
+	assert.NoError(t, conf.Parse())
+
+	var buffer bytes.Buffer
+	assert.NoError(t, conf.Export(ExportFlags, &buffer))
+	assert.Equal(t, "--token=******\n--user=admin\n", buffer.String())
+
+	buffer.Reset()
+	assert.NoError(t, conf.ExportWithOptions(ExportFlags, &buffer, ExportOptions{RevealSecrets: true}))
+	assert.Equal(t, "--token=abc\n--user=admin\n", buffer.String())
+}
+
+func TestConf_ToStruct_SecretTag(t *testing.T) {
+	var testStruct struct {
+		Password    SecretString  `comfyname:"password"`
+		PasswordPtr *SecretString `comfyname:"password"`
+		Token       string        `comfyname:"token" secret:"true"`
+	}
+
+	conf := prepareConf([]string{"--password=hunter2", "--token=abc"}, "=")
+
+	conf.Secret("p", "password", "", "Password")
+	conf.String("t", "token", "", "Token")
+
+	assert.NoError(t, conf.RegisterStruct(&testStruct))
+	assert.NoError(t, conf.Parse())
+
+	var buffer bytes.Buffer
+	assert.NoError(t, conf.Export(ExportFlags, &buffer))
+	assert.Equal(t, "--password=******\n--token=******\n", buffer.String())
+
+	conf.ToStruct(&testStruct)
+
+	assert.Equal(t, "hunter2", testStruct.Password.Reveal())
+	assert.Equal(t, "hunter2", testStruct.PasswordPtr.Reveal())
+	assert.Equal(t, "abc", testStruct.Token)
+	assert.True(t, conf.Lookup("token").IsSecret())
+}
@@ -0,0 +1,379 @@
+package comfyconf
+
+import (
+	"fmt"
+	"strconv"
+)
+
+//Syntax input syntax of JSON middleware
+type Syntax int
+
+const (
+	//SyntaxJSON standard JSON
+	SyntaxJSON Syntax = iota
+	//SyntaxJSONC JSON with `//` and `/* */` comments and trailing commas
+	SyntaxJSONC
+	//SyntaxJSON5 JSONC with unquoted keys, single quoted strings, hexadecimal numbers, leading and trailing
+	//decimal points, explicit plus sign and line continuations in strings
+	SyntaxJSON5
+)
+
+//SetSyntax sets input syntax of configuration files, standard JSON is used by default.
+//Positions in errors point to original source
+func (j *JSON) SetSyntax(syntax Syntax) {
+	j.syntax = syntax
+}
+
+//jsonSource content of configuration file and its translation to standard JSON
+type jsonSource struct {
+	content []byte
+	decoded []byte
+	//offsets source offsets of decoded bytes, nil when decoded content is same as source
+	offsets []int
+	//converted content is generated from YAML or TOML, so it has no positions of source
+	converted bool
+}
+
+//sourceOffset returns offset in source content of byte in decoded content
+func (s *jsonSource) sourceOffset(offset int) int {
+	if s.offsets == nil {
+		return offset
+	}
+
+	if offset < 0 {
+		return 0
+	}
+
+	if offset >= len(s.offsets) {
+		return len(s.content)
+	}
+
+	return s.offsets[offset]
+}
+
+//relaxedSyntaxError error of relaxed syntax with offset in source content
+type relaxedSyntaxError struct {
+	message string
+	offset  int
+}
+
+func (e *relaxedSyntaxError) Error() string {
+	return e.message
+}
+
+//newJSONSource translates content with relaxed syntax to standard JSON
+func newJSONSource(content []byte, syntax Syntax) (*jsonSource, error) {
+	if syntax == SyntaxJSON {
+		return &jsonSource{content: content, decoded: content}, nil
+	}
+
+	t := &relaxedTranslator{
+		src:     content,
+		json5:   syntax == SyntaxJSON5,
+		out:     make([]byte, 0, len(content)),
+		offsets: make([]int, 0, len(content)),
+	}
+
+	if err := t.translate(); err != nil {
+		return nil, err
+	}
+
+	return &jsonSource{content: content, decoded: t.out, offsets: t.offsets}, nil
+}
+
+//relaxedTranslator translates JSONC and JSON5 to standard JSON and keeps source offset of every emitted byte
+type relaxedTranslator struct {
+	src   []byte
+	json5 bool
+	pos   int
+
+	out     []byte
+	offsets []int
+}
+
+func (t *relaxedTranslator) translate() error {
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+
+		switch {
+		case c == '/' && t.peek(1) == '/' || c == '/' && t.peek(1) == '*':
+			if err := t.skipComment(); err != nil {
+				return err
+			}
+		case c == ',':
+			if !t.isTrailingComma() {
+				t.copy(1)
+			} else {
+				t.pos++
+			}
+		case c == '"' || c == '\'' && t.json5:
+			t.translateString(c)
+		case t.json5 && isIdentifierStart(c):
+			t.translateIdentifier()
+		case t.json5 && (c == '+' || c == '-' || c == '.' || c >= '0' && c <= '9'):
+			if err := t.translateNumber(); err != nil {
+				return err
+			}
+		default:
+			t.copy(1)
+		}
+	}
+
+	return nil
+}
+
+func (t *relaxedTranslator) peek(n int) byte {
+	if t.pos+n < len(t.src) {
+		return t.src[t.pos+n]
+	}
+
+	return 0
+}
+
+//copy copies n bytes of source
+func (t *relaxedTranslator) copy(n int) {
+	for i := 0; i < n && t.pos < len(t.src); i++ {
+		t.out = append(t.out, t.src[t.pos])
+		t.offsets = append(t.offsets, t.pos)
+		t.pos++
+	}
+}
+
+//emit emits generated text, that is mapped to provided source offset
+func (t *relaxedTranslator) emit(s string, offset int) {
+	for i := 0; i < len(s); i++ {
+		t.out = append(t.out, s[i])
+		t.offsets = append(t.offsets, offset)
+	}
+}
+
+func (t *relaxedTranslator) skipComment() error {
+	start := t.pos
+
+	if t.peek(1) == '/' {
+		for t.pos < len(t.src) && t.src[t.pos] != '\n' {
+			t.pos++
+		}
+
+		return nil
+	}
+
+	for t.pos += 2; t.pos < len(t.src); t.pos++ {
+		if t.src[t.pos] == '*' && t.peek(1) == '/' {
+			t.pos += 2
+			return nil
+		}
+	}
+
+	return &relaxedSyntaxError{"unterminated comment", start}
+}
+
+//isTrailingComma returns true, if comma at current position is followed only by whitespaces and comments
+//before closing bracket
+func (t *relaxedTranslator) isTrailingComma() bool {
+	for i := t.pos + 1; i < len(t.src); i++ {
+		switch t.src[i] {
+		case ' ', '\t', '\r', '\n':
+		case '}', ']':
+			return true
+		case '/':
+			lookahead := &relaxedTranslator{src: t.src, pos: i}
+
+			if next := lookahead.peek(1); next != '/' && next != '*' || lookahead.skipComment() != nil {
+				return false
+			}
+
+			i = lookahead.pos - 1
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+//translateString copies string and converts single quoted JSON5 strings and escapes to JSON
+func (t *relaxedTranslator) translateString(quote byte) {
+	t.emit(`"`, t.pos)
+	t.pos++
+
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+
+		switch {
+		case c == quote:
+			t.emit(`"`, t.pos)
+			t.pos++
+			return
+		case c == '"':
+			t.emit(`\"`, t.pos)
+			t.pos++
+		case c == '\\' && t.json5:
+			t.translateEscape()
+		case c == '\\':
+			t.copy(2)
+		default:
+			t.copy(1)
+		}
+	}
+}
+
+//translateEscape converts JSON5 escape sequence to JSON
+func (t *relaxedTranslator) translateEscape() {
+	start := t.pos
+	next := t.peek(1)
+
+	switch {
+	case next == '\n':
+		t.pos += 2
+	case next == '\r' && t.peek(2) == '\n':
+		t.pos += 3
+	case next == '\r':
+		t.pos += 2
+	case next == '\'':
+		t.emit("'", start)
+		t.pos += 2
+	case next == 'v':
+		t.emit(`\u000b`, start)
+		t.pos += 2
+	case next == '0' && !(t.peek(2) >= '0' && t.peek(2) <= '9'):
+		t.emit(`\u0000`, start)
+		t.pos += 2
+	case next == 'x' && isHex(t.peek(2)) && isHex(t.peek(3)):
+		t.emit(`\u00`+string(t.src[t.pos+2:t.pos+4]), start)
+		t.pos += 4
+	case next == '"' || next == '\\' || next == '/' || next == 'b' || next == 'f' || next == 'n' ||
+		next == 'r' || next == 't' || next == 'u':
+		t.copy(2)
+	default:
+		//other escaped characters represent themselves
+		t.pos++
+		t.copy(1)
+	}
+}
+
+//translateIdentifier quotes unquoted object key, other identifiers, like `true`, are copied
+func (t *relaxedTranslator) translateIdentifier() {
+	start := t.pos
+	end := t.pos
+
+	for end < len(t.src) && (isIdentifierStart(t.src[end]) || t.src[end] >= '0' && t.src[end] <= '9') {
+		end++
+	}
+
+	identifier := string(t.src[start:end])
+
+	if t.isKey(end) {
+		t.emit(`"`+identifier+`"`, start)
+		t.pos = end
+		return
+	}
+
+	t.copy(end - start)
+}
+
+//isKey returns true, if offset is followed by colon after whitespaces and comments
+func (t *relaxedTranslator) isKey(offset int) bool {
+	for i := offset; i < len(t.src); i++ {
+		switch t.src[i] {
+		case ' ', '\t', '\r', '\n':
+		case ':':
+			return true
+		case '/':
+			lookahead := &relaxedTranslator{src: t.src, pos: i}
+
+			if next := lookahead.peek(1); next != '/' && next != '*' || lookahead.skipComment() != nil {
+				return false
+			}
+
+			i = lookahead.pos - 1
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+//translateNumber converts JSON5 number to JSON number
+func (t *relaxedTranslator) translateNumber() error {
+	start := t.pos
+
+	switch t.src[t.pos] {
+	case '+':
+		t.pos++
+	case '-':
+		t.copy(1)
+	}
+
+	if t.pos < len(t.src) && isIdentifierStart(t.src[t.pos]) {
+		//Infinity and NaN are not supported by JSON, so they are left for decoder error
+		t.translateIdentifier()
+		return nil
+	}
+
+	if t.peek(0) == '0' && (t.peek(1) == 'x' || t.peek(1) == 'X') {
+		end := t.pos + 2
+
+		for end < len(t.src) && isHex(t.src[end]) {
+			end++
+		}
+
+		n, err := strconv.ParseUint(string(t.src[t.pos+2:end]), 16, 64)
+
+		if err != nil {
+			return &relaxedSyntaxError{fmt.Sprintf("invalid hexadecimal number %q", t.src[start:end]), start}
+		}
+
+		t.emit(strconv.FormatUint(n, 10), t.pos)
+		t.pos = end
+
+		return nil
+	}
+
+	if t.peek(0) == '.' {
+		t.emit("0", t.pos)
+	}
+
+	for t.pos < len(t.src) && t.src[t.pos] >= '0' && t.src[t.pos] <= '9' {
+		t.copy(1)
+	}
+
+	if t.peek(0) == '.' {
+		if t.peek(1) >= '0' && t.peek(1) <= '9' {
+			t.copy(1)
+
+			for t.pos < len(t.src) && t.src[t.pos] >= '0' && t.src[t.pos] <= '9' {
+				t.copy(1)
+			}
+		} else {
+			t.pos++
+		}
+	}
+
+	if t.peek(0) == 'e' || t.peek(0) == 'E' {
+		t.copy(1)
+
+		if t.peek(0) == '+' || t.peek(0) == '-' {
+			t.copy(1)
+		}
+
+		for t.pos < len(t.src) && t.src[t.pos] >= '0' && t.src[t.pos] <= '9' {
+			t.copy(1)
+		}
+	}
+
+	if t.pos == start {
+		t.copy(1)
+	}
+
+	return nil
+}
+
+func isIdentifierStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$'
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
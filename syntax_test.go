@@ -0,0 +1,100 @@
+package comfyconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJSONSource_JSONC(t *testing.T) {
+	source, err := newJSONSource([]byte(`{
+		// line comment
+		"url": "http://example.com", /* block
+		comment */ "tags": ["a", "b",],
+	}`), SyntaxJSONC)
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"url": "http://example.com", "tags": ["a", "b"]}`, string(source.decoded))
+
+	_, err = newJSONSource([]byte(`{"a": 1 /* comment`), SyntaxJSONC)
+	assert.Equal(t, &relaxedSyntaxError{"unterminated comment", 8}, err)
+
+	source, err = newJSONSource([]byte(`{key: 'value'}`), SyntaxJSONC)
+	assert.NoError(t, err)
+	assert.Equal(t, `{key: 'value'}`, string(source.decoded))
+}
+
+func TestNewJSONSource_JSON5(t *testing.T) {
+	source, err := newJSONSource([]byte(`{
+		unquoted: 'single "quoted" \'string\'',
+		$key_2: "line \
+continuation",
+		hex: 0xFF,
+		negative: -0x10,
+		leading: .5,
+		trailing: 5.,
+		plus: +1e3,
+		escapes: '\x41\v\0',
+		nested: {true: true, null: null,},
+		list: [1, 2, /* three */],
+	}`), SyntaxJSON5)
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"unquoted": "single \"quoted\" 'string'",
+		"$key_2": "line continuation",
+		"hex": 255,
+		"negative": -16,
+		"leading": 0.5,
+		"trailing": 5,
+		"plus": 1e3,
+		"escapes": "A\u000b\u0000",
+		"nested": {"true": true, "null": null},
+		"list": [1, 2]
+	}`, string(source.decoded))
+
+	assert.Equal(t, len(source.decoded), len(source.offsets))
+}
+
+func TestJSON_SetSyntax(t *testing.T) {
+	dir := prepareJSONFiles(t, map[string]string{
+		"config.json5": "{\n  // database\n  db: {host: 'localhost', port: 0x1538,},\n}",
+		"broken.json5": "{\n  /* comment */ db: {port: ,},\n}",
+		"typed.json5":  "{\n  // comment\n  db: {port: 'http'},\n}",
+	})
+	defer os.RemoveAll(dir)
+
+	j := NewJSON(filepath.Join(dir, "config.json5"))
+	j.SetSyntax(SyntaxJSON5)
+
+	assert.NoError(t, j.Init())
+
+	port, isOk := j.ParseInt("port", "db.port")
+	assert.True(t, isOk)
+	assert.Equal(t, 5432, port)
+
+	path := filepath.Join(dir, "broken.json5")
+	j = NewJSON(path)
+	j.SetSyntax(SyntaxJSON5)
+
+	err := j.Init()
+	assert.IsType(t, &JSONError{}, err)
+	assert.Equal(t, 2, err.(*JSONError).Line)
+	assert.Equal(t, 29, err.(*JSONError).Column)
+	assert.Contains(t, err.Error(), "   2 |   /* comment */ db: {port: ,},\n")
+
+	path = filepath.Join(dir, "typed.json5")
+	j = NewJSON(path)
+	j.SetSyntax(SyntaxJSON5)
+	j.SetStrict(true)
+
+	conf := New(j)
+	conf.Int("", "db.port", 0, "Port")
+
+	assert.EqualError(t, conf.Parse(), "comfyconf: "+path+":3:14: db.port: expected int, got string")
+
+	j = NewJSON(filepath.Join(dir, "config.json5"))
+	assert.Error(t, j.Init())
+}
@@ -0,0 +1,110 @@
+package comfyconf
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+//NewTOML returns pointer to instance of JSON configuration middleware, that reads TOML file.
+//Included files are converted by their extension, so TOML file can include JSON and YAML files
+func NewTOML(file string) *JSON {
+	return &JSON{
+		path:   file,
+		reader: FormatReader,
+		format: "toml",
+	}
+}
+
+//tomlToJSON converts TOML document to JSON. Dates and times are converted to strings, inf and nan are rejected
+func tomlToJSON(content []byte) ([]byte, error) {
+	value, err := parseTOML(content)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(marshalJSON(value)), nil
+}
+
+//parseTOML parses TOML document to JSON compatible maps, slices and scalars
+func parseTOML(content []byte) (map[string]interface{}, error) {
+	value := make(map[string]interface{})
+
+	if _, err := toml.Decode(string(content), &value); err != nil {
+		return nil, err
+	}
+
+	converted, err := tomlJSONValue(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return converted.(map[string]interface{}), nil
+}
+
+//tomlJSONValue converts decoded TOML value to value, that can be encoded to JSON. Arrays of tables are converted
+//to arrays of objects, dates and times are formatted same way as in TOML
+func tomlJSONValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			converted, err := tomlJSONValue(item)
+
+			if err != nil {
+				return nil, err
+			}
+
+			v[key] = converted
+		}
+
+		return v, nil
+	case []map[string]interface{}:
+		items := make([]interface{}, len(v))
+
+		for i, item := range v {
+			converted, err := tomlJSONValue(item)
+
+			if err != nil {
+				return nil, err
+			}
+
+			items[i] = converted
+		}
+
+		return items, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := tomlJSONValue(item)
+
+			if err != nil {
+				return nil, err
+			}
+
+			v[i] = converted
+		}
+
+		return v, nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("toml: %v is not supported", v)
+		}
+	case time.Time:
+		//Local dates and times are decoded with fixed zones, that are not exported by toml package
+		switch v.Location().String() {
+		case "date-local":
+			return v.Format("2006-01-02"), nil
+		case "time-local":
+			return v.Format("15:04:05.999999999"), nil
+		case "datetime-local":
+			return v.Format("2006-01-02T15:04:05.999999999"), nil
+		}
+
+		return v.Format(time.RFC3339Nano), nil
+	}
+
+	return value, nil
+}
@@ -0,0 +1,136 @@
+package comfyconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTOML(t *testing.T) {
+	content := `# application configuration
+name = "app \"main\"" # comment
+path = 'C:\Users\app'
+debug = true
+port = 8_080
+mask = 0o755
+ratio = 1.5e2
+created = 1979-05-27 07:32:00Z
+"quoted key" = 1
+db.host = "localhost"
+tags = [
+  "a",
+  'b', # comment
+]
+point = { x = 1, y = 2 }
+unicode = "\u00e9"
+text = """
+line one
+line \
+  two"""
+raw = '''
+it's raw'''
+
+[server.tls]
+enabled = false
+
+[[plugins]]
+name = "one"
+
+[[plugins]]
+name = "two"
+
+[plugins.options]
+level = 3
+`
+
+	value, err := parseTOML([]byte(content))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"name":       `app "main"`,
+		"path":       `C:\Users\app`,
+		"debug":      true,
+		"port":       int64(8080),
+		"mask":       int64(493),
+		"ratio":      150.0,
+		"created":    "1979-05-27T07:32:00Z",
+		"quoted key": int64(1),
+		"db":         map[string]interface{}{"host": "localhost"},
+		"tags":       []interface{}{"a", "b"},
+		"point":      map[string]interface{}{"x": int64(1), "y": int64(2)},
+		"unicode":    "é",
+		"text":       "line one\nline two",
+		"raw":        "it's raw",
+		"server": map[string]interface{}{
+			"tls": map[string]interface{}{"enabled": false},
+		},
+		"plugins": []interface{}{
+			map[string]interface{}{"name": "one"},
+			map[string]interface{}{"name": "two", "options": map[string]interface{}{"level": int64(3)}},
+		},
+	}, value)
+
+	value, err = parseTOML([]byte("zero = 0\nhalf = -0.5\n[a.b]\n[a]\n[[p]]\n[p.o]\nx = 1\n[[p]]\n[p.o]\nx = 2\n"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"zero": int64(0),
+		"half": -0.5,
+		"a":    map[string]interface{}{"b": map[string]interface{}{}},
+		"p": []interface{}{
+			map[string]interface{}{"o": map[string]interface{}{"x": int64(1)}},
+			map[string]interface{}{"o": map[string]interface{}{"x": int64(2)}},
+		},
+	}, value)
+}
+
+func TestParseTOML_Errors(t *testing.T) {
+	_, err := parseTOML([]byte("a = 1\na = 2\n"))
+	assert.EqualError(t, err, `toml: line 2 (last key "a"): Key 'a' has already been defined.`)
+
+	_, err = parseTOML([]byte("a = \"text\n"))
+	assert.EqualError(t, err, `toml: line 1 (last key "a"): strings cannot contain newlines`)
+
+	_, err = parseTOML([]byte("a = [1, 2\n"))
+	assert.EqualError(t, err, `toml: line 1 (last key "a"): expected a comma (',') or array terminator (']'), but got end of file`)
+
+	_, err = parseTOML([]byte("a = 1 b = 2\n"))
+	assert.EqualError(t, err, `toml: line 1: expected a top-level item to end with a newline, comment, or EOF, but got 'b' instead`)
+
+	_, err = parseTOML([]byte("a = nan\n"))
+	assert.EqualError(t, err, "toml: NaN is not supported")
+
+	_, err = parseTOML([]byte("a = 1\n[a.b]\n"))
+	assert.EqualError(t, err, `toml: line 2: Key 'a' was already created as a hash.`)
+
+	_, err = parseTOML([]byte("[a]\nb = 1\n[a]\nc = 2\n"))
+	assert.EqualError(t, err, `toml: line 3: Key 'a' has already been defined.`)
+
+	_, err = parseTOML([]byte("a = 01\n"))
+	assert.EqualError(t, err, `toml: line 1 (last key "a"): Invalid integer "01": cannot have leading zeroes`)
+
+	_, err = parseTOML([]byte("a = -00.5\n"))
+	assert.EqualError(t, err, `toml: line 1 (last key "a"): Invalid float "-00.5": cannot have leading zeroes`)
+
+	_, err = parseTOML([]byte("a = {b = 1}\n[a]\nc = 2\n"))
+	assert.EqualError(t, err, `toml: line 2: Key 'a' has already been defined.`)
+
+	_, err = parseTOML([]byte("a = -inf\n"))
+	assert.EqualError(t, err, "toml: -Inf is not supported")
+}
+
+func TestParseTOML_LocalDates(t *testing.T) {
+	value, err := parseTOML([]byte("date = 1979-05-27\ntime = 07:32:00.5\ndatetime = 1979-05-27T07:32:00\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"date":     "1979-05-27",
+		"time":     "07:32:00.5",
+		"datetime": "1979-05-27T07:32:00",
+	}, value)
+}
+
+func TestTOMLToJSON(t *testing.T) {
+	content, err := tomlToJSON([]byte("[db]\nport = 5432\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"db":{"port":5432}}`, string(content))
+}
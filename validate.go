@@ -0,0 +1,240 @@
+package comfyconf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//Validator validates option value after parsing
+type Validator interface {
+	//Validate returns error, if value is not valid
+	Validate(value interface{}) error
+}
+
+//ValidatorFunc adapter for using ordinary functions as validators
+type ValidatorFunc func(value interface{}) error
+
+//Validate calls f(value)
+func (f ValidatorFunc) Validate(value interface{}) error {
+	return f(value)
+}
+
+//OneOf returns validator, that allows only provided values. Values are also suggested by shell completion
+func OneOf(values ...string) *OneOfValidator {
+	return &OneOfValidator{values}
+}
+
+//OneOfValidator validator of enum values. Every item of slice option must be one of allowed values
+type OneOfValidator struct {
+	values []string
+}
+
+//Values returns allowed values
+func (v *OneOfValidator) Values() []string {
+	return v.values
+}
+
+//Validate checks that value is one of allowed values
+func (v *OneOfValidator) Validate(value interface{}) error {
+	return v.validate(value, false)
+}
+
+func (v *OneOfValidator) validateSecret(value interface{}) error {
+	return v.validate(value, true)
+}
+
+func (v *OneOfValidator) validate(value interface{}, isSecret bool) error {
+	if items, isOk := value.([]interface{}); isOk {
+		for _, item := range items {
+			if err := v.validate(item, isSecret); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	s := fmt.Sprint(value)
+
+	for _, allowed := range v.values {
+		if s == allowed {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("value %s is not one of %s", quoteValue(s, isSecret), strings.Join(v.values, ", "))
+}
+
+//Min returns validator, that allows only numbers greater than or equal to min
+func Min(min int) *RangeValidator {
+	return &RangeValidator{min: &min}
+}
+
+//Max returns validator, that allows only numbers less than or equal to max
+func Max(max int) *RangeValidator {
+	return &RangeValidator{max: &max}
+}
+
+//Range returns validator, that allows only numbers between min and max inclusive
+func Range(min int, max int) *RangeValidator {
+	return &RangeValidator{min: &min, max: &max}
+}
+
+//RangeValidator validator of number bounds. Every item of slice option must be in range.
+//Strings are converted to numbers, fractional numbers are allowed
+type RangeValidator struct {
+	min *int
+	max *int
+}
+
+//Bounds returns lower and upper bounds, nil bound is not checked
+func (v *RangeValidator) Bounds() (min *int, max *int) {
+	return v.min, v.max
+}
+
+//Validate checks that value is in range. Numbers are compared as float64, so 5.9 is out of Range(0, 5)
+func (v *RangeValidator) Validate(value interface{}) error {
+	return v.validate(value, false)
+}
+
+func (v *RangeValidator) validateSecret(value interface{}) error {
+	return v.validate(value, true)
+}
+
+func (v *RangeValidator) validate(value interface{}, isSecret bool) error {
+	var n float64
+
+	switch value := value.(type) {
+	case []interface{}:
+		for _, item := range value {
+			if err := v.validate(item, isSecret); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case int:
+		n = float64(value)
+	case float64:
+		n = value
+	default:
+		converted, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+
+		if err != nil {
+			return fmt.Errorf("value %s is not a number", quoteValue(fmt.Sprint(value), isSecret))
+		}
+
+		n = converted
+	}
+
+	text := strconv.FormatFloat(n, 'f', -1, 64)
+
+	if isSecret {
+		text = Redacted
+	}
+
+	if v.min != nil && n < float64(*v.min) {
+		return fmt.Errorf("value %s is less than %d", text, *v.min)
+	}
+
+	if v.max != nil && n > float64(*v.max) {
+		return fmt.Errorf("value %s is greater than %d", text, *v.max)
+	}
+
+	return nil
+}
+
+//secretValidator is implemented by validators, that can describe error without value of secret option
+type secretValidator interface {
+	validateSecret(value interface{}) error
+}
+
+//validateValue checks value by validator, value of secret option is redacted in error of built-in validators
+func validateValue(validator Validator, value interface{}, isSecret bool) error {
+	if secret, isOk := validator.(secretValidator); isOk && isSecret {
+		return secret.validateSecret(value)
+	}
+
+	return validator.Validate(value)
+}
+
+//quoteValue quotes value for error message, value of secret option is redacted
+func quoteValue(value string, isSecret bool) string {
+	if isSecret {
+		return Redacted
+	}
+
+	return strconv.Quote(value)
+}
+
+//AddValidator adds validators to option, that are checked at the end of Parse.
+//Errors of OneOf and Range validators do not contain value of secret option, custom validators should not show it too
+func (o *Option) AddValidator(validators ...Validator) {
+	o.validators = append(o.validators, validators...)
+}
+
+//AddValidator adds validators to option with provided full or short name
+func (c *Conf) AddValidator(name string, validators ...Validator) {
+	if opt := c.Lookup(name); opt != nil {
+		opt.AddValidator(validators...)
+	}
+}
+
+//SetRequired marks option as required. Parse fails, when required option was not provided by any middleware
+func (o *Option) SetRequired(required bool) {
+	o.required = required
+}
+
+//IsRequired returns true, if option is required
+func (o *Option) IsRequired() bool {
+	return o.required
+}
+
+//SetRequired marks options with provided full or short names as required
+func (c *Conf) SetRequired(names ...string) {
+	for _, name := range names {
+		if opt := c.Lookup(name); opt != nil {
+			opt.SetRequired(true)
+		}
+	}
+}
+
+//validate checks required options and values of all options in declaration order
+func (c *Conf) validate() error {
+	for _, opt := range c.sortedOptions() {
+		if opt.required && len(opt.source) == 0 {
+			return fmt.Errorf("comfyconf: option %q: value is required", opt.name())
+		}
+
+		for _, validator := range opt.validators {
+			if err := validateValue(validator, opt.GetValue(), opt.secret); err != nil {
+				return fmt.Errorf("comfyconf: option %q: %v", opt.name(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+//enumValues returns allowed values of option from OneOf validators
+func (o *Option) enumValues() []string {
+	values := make([]string, 0)
+
+	for _, validator := range o.validators {
+		if oneOf, isOk := validator.(*OneOfValidator); isOk {
+			values = append(values, oneOf.Values()...)
+		}
+	}
+
+	return values
+}
+
+//name returns full name of option or short name, if option has no full name
+func (o *Option) name() string {
+	if len(o.key.fullName) != 0 {
+		return o.key.fullName
+	}
+
+	return o.key.shortName
+}
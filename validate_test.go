@@ -0,0 +1,90 @@
+package comfyconf
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOneOfValidator_Validate(t *testing.T) {
+	v := OneOf("debug", "info")
+
+	assert.Equal(t, []string{"debug", "info"}, v.Values())
+	assert.NoError(t, v.Validate("info"))
+	assert.NoError(t, v.Validate([]interface{}{"debug", "info"}))
+	assert.EqualError(t, v.Validate("trace"), `value "trace" is not one of debug, info`)
+	assert.EqualError(t, v.Validate([]interface{}{"debug", "trace"}), `value "trace" is not one of debug, info`)
+	assert.NoError(t, OneOf("1", "2").Validate(2))
+}
+
+func TestConf_Parse_Validators(t *testing.T) {
+	conf := prepareConf([]string{"--level=trace"}, "=")
+	conf.String("l", "level", "info", "Log level")
+	conf.AddValidator("level", OneOf("debug", "info"))
+
+	assert.EqualError(t, conf.Parse(), `comfyconf: option "level": value "trace" is not one of debug, info`)
+
+	conf = prepareConf([]string{"-p=0"}, "=")
+	conf.Int("p", "", 80, "Port")
+	conf.AddValidator("p", ValidatorFunc(func(value interface{}) error {
+		if value.(int) <= 0 {
+			return errors.New("must be positive")
+		}
+		return nil
+	}))
+
+	assert.EqualError(t, conf.Parse(), `comfyconf: option "p": must be positive`)
+
+	conf = prepareConf([]string{"--level=debug"}, "=")
+	level := conf.String("l", "level", "info", "Log level")
+	conf.AddValidator("level", OneOf("debug", "info"))
+
+	assert.NoError(t, conf.Parse())
+	assert.Equal(t, "debug", *level)
+}
+
+func TestRangeValidator_Validate(t *testing.T) {
+	assert.NoError(t, Range(1, 10).Validate(5))
+	assert.NoError(t, Min(1).Validate("7"))
+	assert.NoError(t, Max(10).Validate([]interface{}{"1", float64(2)}))
+	assert.EqualError(t, Min(1).Validate(0), "value 0 is less than 1")
+	assert.EqualError(t, Max(10).Validate([]interface{}{"11"}), "value 11 is greater than 10")
+	assert.EqualError(t, Range(1, 10).Validate("abc"), `value "abc" is not a number`)
+	assert.EqualError(t, Range(0, 5).Validate(5.9), "value 5.9 is greater than 5")
+	assert.EqualError(t, Min(1).Validate("0.5"), "value 0.5 is less than 1")
+	assert.NoError(t, Range(0, 5).Validate(4.5))
+}
+
+func TestConf_Parse_Validators_Secret(t *testing.T) {
+	conf := prepareConf([]string{"--token=hunter2"}, "=")
+	conf.Secret("", "token", "", "Token")
+	conf.AddValidator("token", OneOf("a", "b"))
+
+	assert.EqualError(t, conf.Parse(), `comfyconf: option "token": value ****** is not one of a, b`)
+
+	conf = prepareConf([]string{"--pin=12345"}, "=")
+	conf.Secret("", "pin", "", "PIN")
+	conf.AddValidator("pin", Max(9999))
+
+	assert.EqualError(t, conf.Parse(), `comfyconf: option "pin": value ****** is greater than 9999`)
+
+	conf = prepareConf([]string{"--pin=abc"}, "=")
+	conf.Secret("", "pin", "", "PIN")
+	conf.AddValidator("pin", Max(9999))
+
+	assert.EqualError(t, conf.Parse(), `comfyconf: option "pin": value ****** is not a number`)
+}
+
+func TestConf_Parse_Required(t *testing.T) {
+	conf := prepareConf([]string{}, "=")
+	conf.String("", "db.host", "localhost", "Database host")
+	conf.SetRequired("db.host")
+
+	assert.True(t, conf.Lookup("db.host").IsRequired())
+	assert.EqualError(t, conf.Parse(), `comfyconf: option "db.host": value is required`)
+
+	conf.AddMiddleware(prepareFlags([]string{"--db.host=db.local"}, "="))
+
+	assert.NoError(t, conf.Parse())
+}
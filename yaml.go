@@ -0,0 +1,114 @@
+package comfyconf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//NewYAML returns pointer to instance of JSON configuration middleware, that reads YAML file.
+//Included files are converted by their extension, so YAML file can include JSON and TOML files
+func NewYAML(file string) *JSON {
+	return &JSON{
+		path:   file,
+		reader: FormatReader,
+		format: "yaml",
+	}
+}
+
+//yamlToJSON converts YAML document to JSON. Anchors, aliases, merge keys and tags are resolved by YAML decoder.
+//Multiple documents, infinite and NaN numbers are not supported
+func yamlToJSON(content []byte) ([]byte, error) {
+	value, err := parseYAML(content)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		value = make(map[string]interface{})
+	}
+
+	return []byte(marshalJSON(value)), nil
+}
+
+//parseYAML parses YAML document to JSON compatible maps, slices and scalars
+func parseYAML(content []byte) (interface{}, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+	var value interface{}
+
+	if err := decoder.Decode(&value); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	var next interface{}
+
+	if err := decoder.Decode(&next); err != io.EOF {
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, errors.New("yaml: multiple documents are not supported")
+	}
+
+	return yamlJSONValue(value)
+}
+
+//yamlJSONValue converts decoded YAML value to value, that can be encoded to JSON. Keys of mappings are converted
+//to strings and timestamps are formatted as RFC 3339 strings
+func yamlJSONValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			converted, err := yamlJSONValue(item)
+
+			if err != nil {
+				return nil, err
+			}
+
+			v[key] = converted
+		}
+
+		return v, nil
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(v))
+
+		for key, item := range v {
+			converted, err := yamlJSONValue(item)
+
+			if err != nil {
+				return nil, err
+			}
+
+			object[fmt.Sprint(key)] = converted
+		}
+
+		return object, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := yamlJSONValue(item)
+
+			if err != nil {
+				return nil, err
+			}
+
+			v[i] = converted
+		}
+
+		return v, nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("yaml: %v is not supported", v)
+		}
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	}
+
+	return value, nil
+}
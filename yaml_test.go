@@ -0,0 +1,128 @@
+package comfyconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseYAML(t *testing.T) {
+	content := `---
+# application configuration
+name: "app: main"
+debug: true
+ratio: 0.5
+port: 0x1F90
+empty:
+quoted: 'it''s'
+db:
+  host: localhost # inline comment
+  port: 5432
+  tags: [primary, "eu-west", 3]
+  options: {ssl: true, mode: strict}
+hosts:
+- a
+- b
+servers:
+  - host: one
+    port: 1
+  - host: two
+    weights:
+      - 1
+      - - 2
+        - 3
+certificate: |
+  line one
+  line two
+
+motd: >-
+  folded
+  text
+`
+
+	value, err := parseYAML([]byte(content))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"name":   "app: main",
+		"debug":  true,
+		"ratio":  0.5,
+		"port":   8080,
+		"empty":  nil,
+		"quoted": "it's",
+		"db": map[string]interface{}{
+			"host":    "localhost",
+			"port":    5432,
+			"tags":    []interface{}{"primary", "eu-west", 3},
+			"options": map[string]interface{}{"ssl": true, "mode": "strict"},
+		},
+		"hosts": []interface{}{"a", "b"},
+		"servers": []interface{}{
+			map[string]interface{}{"host": "one", "port": 1},
+			map[string]interface{}{"host": "two", "weights": []interface{}{1, []interface{}{2, 3}}},
+		},
+		"certificate": "line one\nline two\n",
+		"motd":        "folded text",
+	}, value)
+}
+
+func TestParseYAML_Anchors(t *testing.T) {
+	content := `base: &base
+  host: localhost
+  port: 5432
+primary:
+  <<: *base
+  host: primary
+replica: *base
+tags: [a,
+  b]
+1: numeric key
+when: 2024-01-02T03:04:05Z
+`
+
+	value, err := parseYAML([]byte(content))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"base":    map[string]interface{}{"host": "localhost", "port": 5432},
+		"primary": map[string]interface{}{"host": "primary", "port": 5432},
+		"replica": map[string]interface{}{"host": "localhost", "port": 5432},
+		"tags":    []interface{}{"a", "b"},
+		"1":       "numeric key",
+		"when":    "2024-01-02T03:04:05Z",
+	}, value)
+}
+
+func TestParseYAML_Errors(t *testing.T) {
+	_, err := parseYAML([]byte("db:\n  host: a\n    port: 1\n"))
+	assert.EqualError(t, err, "yaml: line 3: mapping values are not allowed in this context")
+
+	_, err = parseYAML([]byte("name: [a, b\n"))
+	assert.EqualError(t, err, "yaml: line 1: did not find expected ',' or ']'")
+
+	_, err = parseYAML([]byte("a: 1\n\tb: 2"))
+	assert.EqualError(t, err, "yaml: line 2: found a tab character that violates indentation")
+
+	_, err = parseYAML([]byte("a: *missing\n"))
+	assert.EqualError(t, err, "yaml: unknown anchor 'missing' referenced")
+
+	_, err = parseYAML([]byte("a: 1\n---\nb: 2\n"))
+	assert.EqualError(t, err, "yaml: multiple documents are not supported")
+
+	_, err = parseYAML([]byte("a: .inf\n"))
+	assert.EqualError(t, err, "yaml: +Inf is not supported")
+
+	value, err := parseYAML([]byte("---\na: 1\n...\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1}, value)
+}
+
+func TestYAMLToJSON(t *testing.T) {
+	content, err := yamlToJSON([]byte("a:\n  b: [1, x]\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":{"b":[1,"x"]}}`, string(content))
+
+	content, err = yamlToJSON([]byte("# empty\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{}`, string(content))
+}